@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+
+	"ftoolbox/fansly/fakefansly"
+
+	"go.uber.org/zap"
+)
+
+// Runs the fake Fansly API as a standalone server. Point the backend at it with
+// FANSLY_BASE_URL=http://<addr>/api/v1 to run workers without the real API.
+func main() {
+	addr := flag.String("addr", ":3100", "address to listen on")
+	fixturesPath := flag.String("fixtures", "", "path to a JSON fixtures file")
+	flag.Parse()
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Sync()
+
+	server := fakefansly.New()
+	if *fixturesPath != "" {
+		fixtures, err := fakefansly.LoadFixtures(*fixturesPath)
+		if err != nil {
+			logger.Fatal("Failed to load fixtures", zap.Error(err))
+		}
+		server = fakefansly.NewWithFixtures(fixtures)
+		logger.Info("Loaded fixtures",
+			zap.Int("tags", len(fixtures.Tags)),
+			zap.Int("suggestions", len(fixtures.Suggestions)),
+			zap.Int("accounts", len(fixtures.Accounts)))
+	}
+
+	logger.Info("Fake Fansly API listening",
+		zap.String("addr", *addr),
+		zap.String("prefix", fakefansly.APIPrefix))
+	if err := http.ListenAndServe(*addr, server.Handler()); err != nil {
+		logger.Fatal("Fake Fansly API stopped", zap.Error(err))
+	}
+}
@@ -1,52 +1,114 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	DBHost                   string
-	DBPort                   string
-	DBUsername               string
-	DBPassword               string
-	DBDatabase               string
-	Port                     string
-	LogLevel                 string
-	WorkerEnabled            bool
-	WorkerUpdateInterval     int
-	WorkerDiscoveryInterval  int
-	RankCalculationInterval  int
-	WorkerStatisticsInterval int
-	WorkerTagCleanupInterval int
-	GlobalRateLimit          int
-	GlobalRateLimitWindow    int
+	DBHost                    string
+	DBPort                    string
+	DBUsername                string
+	DBPassword                string
+	DBDatabase                string
+	Port                      string
+	LogLevel                  string
+	WorkerEnabled             bool
+	WorkerUpdateInterval      int
+	WorkerDiscoveryInterval   int
+	RankCalculationInterval   int
+	WorkerStatisticsInterval  int
+	WorkerTagCleanupInterval  int
+	WorkerHeatInterval        int
+	GlobalRateLimit           int
+	GlobalRateLimitWindow     int
+	FanslyBaseURL             string
+	InstanceID                string
+	WorkerLeaseDuration       int
+	AdminAPIKey               string
+	WorkerTimeouts            map[string]time.Duration
+	WorkerSchedules           map[string]string
+	WorkerJitter              map[string]time.Duration
+	WorkerFailureBackoff      int
+	WorkerMaxFailureBackoff   int
+	FanslyBreakerThreshold    int
+	FanslyBreakerCooldown     int
+	TagRefreshBatchSize       int
+	TagRefreshConcurrency     int
+	TagRefreshMinInterval     int
+	TagRefreshMaxInterval     int
+	WorkerCreatorInterval     int
+	CreatorRefreshBatchSize   int
+	CreatorRefreshMinInterval int
+	CreatorRefreshMaxInterval int
+	DiscoveryPageSize         int
+	DiscoveryPagesPerRun      int
+	DiscoveryMaxPassPages     int
+	DiscoverySeedTags         []string
+	DiscoveryHashtagBudget    int
 }
 
 func Load() *Config {
 	godotenv.Load()
 
 	return &Config{
-		DBHost:                   getEnv("DB_HOST", "localhost"),
-		DBPort:                   getEnv("DB_PORT", "3306"),
-		DBUsername:               getEnv("DB_USERNAME", "mysql"),
-		DBPassword:               getEnv("DB_PASSWORD", "mysql"),
-		DBDatabase:               getEnv("DB_DATABASE", "ftoolbox"),
-		Port:                     getEnv("PORT", "3000"),
-		LogLevel:                 getEnv("LOG_LEVEL", "info"),
-		WorkerEnabled:            getEnvBool("WORKER_ENABLED", true),
-		WorkerUpdateInterval:     getEnvInt("WORKER_UPDATE_INTERVAL", 10000),
-		WorkerDiscoveryInterval:  getEnvInt("WORKER_DISCOVERY_INTERVAL", 60000*10),
-		RankCalculationInterval:  getEnvInt("RANK_CALCULATION_INTERVAL", 60000*10),
-		WorkerStatisticsInterval: getEnvInt("WORKER_STATISTICS_INTERVAL", 3600000), // Default to 1 hour
-		WorkerTagCleanupInterval: getEnvInt("WORKER_TAG_CLEANUP_INTERVAL", 3600000),
-		GlobalRateLimit:          getEnvInt("FANSLY_GLOBAL_RATE_LIMIT", 50),
-		GlobalRateLimitWindow:    getEnvInt("FANSLY_GLOBAL_RATE_LIMIT_WINDOW", 10),
+		DBHost:                    getEnv("DB_HOST", "localhost"),
+		DBPort:                    getEnv("DB_PORT", "3306"),
+		DBUsername:                getEnv("DB_USERNAME", "mysql"),
+		DBPassword:                getEnv("DB_PASSWORD", "mysql"),
+		DBDatabase:                getEnv("DB_DATABASE", "ftoolbox"),
+		Port:                      getEnv("PORT", "3000"),
+		LogLevel:                  getEnv("LOG_LEVEL", "info"),
+		WorkerEnabled:             getEnvBool("WORKER_ENABLED", true),
+		WorkerUpdateInterval:      getEnvInt("WORKER_UPDATE_INTERVAL", 10000),
+		WorkerDiscoveryInterval:   getEnvInt("WORKER_DISCOVERY_INTERVAL", 60000*10),
+		RankCalculationInterval:   getEnvInt("RANK_CALCULATION_INTERVAL", 60000*10),
+		WorkerStatisticsInterval:  getEnvInt("WORKER_STATISTICS_INTERVAL", 3600000), // Default to 1 hour
+		WorkerTagCleanupInterval:  getEnvInt("WORKER_TAG_CLEANUP_INTERVAL", 3600000),
+		WorkerHeatInterval:        getEnvInt("WORKER_HEAT_INTERVAL", 60000*15),
+		GlobalRateLimit:           getEnvInt("FANSLY_GLOBAL_RATE_LIMIT", 50),
+		GlobalRateLimitWindow:     getEnvInt("FANSLY_GLOBAL_RATE_LIMIT_WINDOW", 10),
+		FanslyBaseURL:             getEnv("FANSLY_BASE_URL", ""),
+		InstanceID:                getEnv("INSTANCE_ID", defaultInstanceID()),
+		WorkerLeaseDuration:       getEnvInt("WORKER_LEASE_DURATION", 60000),
+		AdminAPIKey:               getEnv("ADMIN_API_KEY", ""),
+		WorkerTimeouts:            getEnvDurationMap("WORKER_TIMEOUTS"),
+		WorkerSchedules:           getEnvMap("WORKER_SCHEDULES", ";"),
+		WorkerJitter:              getEnvDurationMap("WORKER_JITTER"),
+		WorkerFailureBackoff:      getEnvInt("WORKER_FAILURE_BACKOFF", 30000),
+		WorkerMaxFailureBackoff:   getEnvInt("WORKER_MAX_FAILURE_BACKOFF", 3600000),
+		FanslyBreakerThreshold:    getEnvInt("FANSLY_CIRCUIT_BREAKER_THRESHOLD", 5),
+		FanslyBreakerCooldown:     getEnvInt("FANSLY_CIRCUIT_BREAKER_COOLDOWN", 60000),
+		TagRefreshBatchSize:       getEnvInt("TAG_REFRESH_BATCH_SIZE", 20),
+		TagRefreshConcurrency:     getEnvInt("TAG_REFRESH_CONCURRENCY", 4),
+		TagRefreshMinInterval:     getEnvInt("TAG_REFRESH_MIN_INTERVAL", 3600000),   // Hot tags: hourly
+		TagRefreshMaxInterval:     getEnvInt("TAG_REFRESH_MAX_INTERVAL", 604800000), // Stagnant tags: weekly
+		WorkerCreatorInterval:     getEnvInt("WORKER_CREATOR_UPDATE_INTERVAL", 10000),
+		CreatorRefreshBatchSize:   getEnvInt("CREATOR_REFRESH_BATCH_SIZE", 100),
+		CreatorRefreshMinInterval: getEnvInt("CREATOR_REFRESH_MIN_INTERVAL", 3600000),
+		CreatorRefreshMaxInterval: getEnvInt("CREATOR_REFRESH_MAX_INTERVAL", 604800000),
+		DiscoveryPageSize:         getEnvInt("DISCOVERY_PAGE_SIZE", 20),
+		DiscoveryPagesPerRun:      getEnvInt("DISCOVERY_PAGES_PER_RUN", 5),
+		DiscoveryMaxPassPages:     getEnvInt("DISCOVERY_MAX_PASS_PAGES", 50),
+		DiscoveryHashtagBudget:    getEnvInt("DISCOVERY_HASHTAG_BUDGET", 5),
+		DiscoverySeedTags:         getEnvList("DISCOVERY_SEED_TAGS", "amateur,teen,milf,anal,blonde,brunette,redhead"),
 	}
 }
 
+// defaultInstanceID identifies this process among replicas sharing a database
+func defaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -71,3 +133,41 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvList parses a comma-separated list, dropping empty entries
+func getEnvList(key, defaultValue string) []string {
+	var result []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getEnvDurationMap parses "name=duration" pairs separated by commas,
+// e.g. "tag-updater=5m,tag-discovery=15m". Invalid entries are skipped.
+func getEnvDurationMap(key string) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for name, value := range getEnvMap(key, ",") {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			result[name] = parsed
+		}
+	}
+	return result
+}
+
+// getEnvMap parses "name=value" pairs separated by sep. Values containing commas,
+// such as cron expressions, need a different separator.
+func getEnvMap(key, sep string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(key), sep) {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			continue
+		}
+		result[name] = strings.TrimSpace(value)
+	}
+	return result
+}
@@ -2,12 +2,16 @@ package database
 
 import (
 	"ftoolbox/models"
+	"ftoolbox/tagfilter"
 
 	"gorm.io/gorm"
 )
 
 func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	hasFilterRules := db.Migrator().HasTable(&models.TagFilterRule{})
+	hasStatusEvents := db.Migrator().HasTable(&models.TagStatusEvent{})
+
+	if err := db.AutoMigrate(
 		&models.Tag{},
 		&models.TagHistory{},
 		&models.Worker{},
@@ -16,5 +20,63 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.TagStatistics{},
 		&models.CreatorStatistics{},
 		&models.TagRelationDaily{},
-	)
+		&models.WorkerRun{},
+		&models.DiscoveryProgress{},
+		&models.DiscoveryFrontier{},
+		&models.TagFilterRule{},
+		&models.Post{},
+		&models.PostTag{},
+		&models.PostHistory{},
+		&models.CreatorTagDaily{},
+		&models.HashtagCandidate{},
+		&models.TagStatusEvent{},
+		&models.TagRankDaily{},
+		&models.CreatorRankDaily{},
+	); err != nil {
+		return err
+	}
+
+	if err := backfillRefreshSchedules(db); err != nil {
+		return err
+	}
+	if !hasStatusEvents {
+		if err := backfillTagBanEvents(db); err != nil {
+			return err
+		}
+	}
+	if !hasFilterRules {
+		return seedTagFilterRules(db)
+	}
+	return nil
+}
+
+// seedTagFilterRules installs the default tag filter rules when the table is created,
+// so rules an admin deletes later stay deleted
+func seedTagFilterRules(db *gorm.DB) error {
+	rules := tagfilter.DefaultRules()
+	return db.Create(&rules).Error
+}
+
+// backfillRefreshSchedules gives tags and creators checked before adaptive
+// scheduling the 24h due time they had, instead of making them all due at once
+func backfillRefreshSchedules(db *gorm.DB) error {
+	for _, model := range []any{&models.Tag{}, &models.Creator{}} {
+		if err := db.Model(model).
+			Where("next_refresh_at IS NULL AND last_checked_at IS NOT NULL").
+			UpdateColumn("next_refresh_at", gorm.Expr("DATE_ADD(last_checked_at, INTERVAL 1 DAY)")).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillTagBanEvents records when the tags deleted before status events were
+// tracked were banned, so their detail shows the ban
+func backfillTagBanEvents(db *gorm.DB) error {
+	return db.Exec(`
+		INSERT INTO tag_status_events (tag_id, event, created_at)
+		SELECT id, ?, COALESCE(deleted_detected_at, updated_at)
+		FROM tags
+		WHERE is_deleted = ?
+	`, models.TagEventBanned, true).Error
 }
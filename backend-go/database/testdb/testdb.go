@@ -0,0 +1,69 @@
+// Package testdb gives tests a freshly migrated database of their own. Tests using
+// it are skipped unless TEST_DATABASE_DSN points at a MySQL or MariaDB server the
+// tests may create databases on, e.g. root:root@tcp(localhost:3306)/?parseTime=True
+package testdb
+
+import (
+	"fmt"
+	"ftoolbox/database"
+	"ftoolbox/tagfilter"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var databases atomic.Int64
+
+// Open creates and migrates a database that is dropped when the test ends. The
+// tag filter rule cache is reset so the test sees the new database's rules.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("invalid TEST_DATABASE_DSN: %v", err)
+	}
+	cfg.ParseTime = true
+	cfg.Loc = time.Local
+
+	server, err := gorm.Open(gormmysql.Open(cfg.FormatDSN()), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to connect to the test database server: %v", err)
+	}
+	name := fmt.Sprintf("ftoolbox_test_%d_%d", os.Getpid(), databases.Add(1))
+	if err := server.Exec("CREATE DATABASE " + name + " CHARACTER SET utf8mb4").Error; err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+
+	cfg.DBName = name
+	db, err := gorm.Open(gormmysql.Open(cfg.FormatDSN()), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		server.Exec("DROP DATABASE " + name)
+		if sqlDB, err := server.DB(); err == nil {
+			sqlDB.Close()
+		}
+		tagfilter.Invalidate()
+	})
+
+	if err := database.AutoMigrate(db); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	tagfilter.Invalidate()
+	return db
+}
@@ -0,0 +1,40 @@
+package fansly
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// API is the subset of the Fansly API used by workers and handlers.
+// It is satisfied by *Client and can be swapped for a fake in tests.
+type API interface {
+	GetTagWithContext(ctx context.Context, tagName string) (*TagResponseData, error)
+	GetSuggestionsData(ctx context.Context, tagIDs []string, before, after string, limit, offset int) (*SuggestionsResponseData, error)
+	GetAccountsWithContext(ctx context.Context, accountIDs []string) ([]FanslyAccount, error)
+	GetAccountByUsername(ctx context.Context, username string) (*FanslyAccount, error)
+}
+
+var _ API = (*Client)(nil)
+
+// RequestCounter counts HTTP requests sent to Fansly, including retries
+type RequestCounter struct {
+	count atomic.Int64
+}
+
+// Count returns the number of requests recorded so far
+func (r *RequestCounter) Count() int64 {
+	return r.count.Load()
+}
+
+type requestCounterKey struct{}
+
+// WithRequestCounter returns a context whose Fansly requests are recorded in counter
+func WithRequestCounter(ctx context.Context, counter *RequestCounter) context.Context {
+	return context.WithValue(ctx, requestCounterKey{}, counter)
+}
+
+func countRequest(ctx context.Context) {
+	if counter, ok := ctx.Value(requestCounterKey{}).(*RequestCounter); ok && counter != nil {
+		counter.count.Add(1)
+	}
+}
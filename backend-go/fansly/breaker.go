@@ -0,0 +1,184 @@
+package fansly
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = time.Minute
+	maxBreakerCooldown      = 30 * time.Minute
+)
+
+var (
+	// ErrUnavailable wraps failures caused by Fansly being unreachable, erroring or
+	// still rate limiting after retries, as opposed to problems with a single request
+	ErrUnavailable = errors.New("fansly: API unavailable")
+	// ErrCircuitOpen is returned without contacting Fansly while the circuit breaker is open
+	ErrCircuitOpen = fmt.Errorf("%w: circuit breaker open", ErrUnavailable)
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerClosed:
+		return "closed"
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops all Fansly requests after consecutive upstream failures.
+// Once the cooldown passes a single probe request is let through: success closes
+// the circuit, failure reopens it with twice the cooldown.
+type CircuitBreaker struct {
+	mu            sync.Mutex
+	state         breakerState
+	failures      int
+	threshold     int
+	baseCooldown  time.Duration
+	cooldown      time.Duration
+	openUntil     time.Time
+	probeInFlight bool
+	openCount     int64
+	logger        *zap.Logger
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration, logger *zap.Logger) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = defaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	return &CircuitBreaker{
+		threshold:    threshold,
+		baseCooldown: cooldown,
+		cooldown:     cooldown,
+		logger:       logger,
+	}
+}
+
+// Allow reports whether a request may be sent, returning ErrCircuitOpen if not
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Now().Before(b.openUntil) {
+			return ErrCircuitOpen
+		}
+		b.state = breakerHalfOpen
+		b.probeInFlight = true
+		b.logger.Info("Circuit breaker half-open, sending probe request")
+		return nil
+	case breakerHalfOpen:
+		if b.probeInFlight {
+			return ErrCircuitOpen
+		}
+		b.probeInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// RecordSuccess closes the circuit after any response showing Fansly is reachable
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != breakerClosed {
+		b.logger.Info("Circuit breaker closed, Fansly is reachable again")
+	}
+	b.state = breakerClosed
+	b.failures = 0
+	b.cooldown = b.baseCooldown
+	b.probeInFlight = false
+}
+
+// RecordFailure counts an upstream failure and opens the circuit at the threshold
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	switch {
+	case b.state == breakerHalfOpen:
+		b.cooldown = min(b.cooldown*2, maxBreakerCooldown)
+		b.openLocked()
+	case b.state == breakerClosed && b.failures >= b.threshold:
+		b.openLocked()
+	}
+}
+
+// RecordAbort releases a probe whose request ended without an answer from Fansly,
+// e.g. because its context was cancelled
+func (b *CircuitBreaker) RecordAbort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probeInFlight = false
+}
+
+func (b *CircuitBreaker) openLocked() {
+	b.state = breakerOpen
+	b.openUntil = time.Now().Add(b.cooldown)
+	b.probeInFlight = false
+	b.openCount++
+	b.logger.Warn("Circuit breaker opened, pausing Fansly requests",
+		zap.Int("consecutive_failures", b.failures),
+		zap.Duration("cooldown", b.cooldown))
+}
+
+// IsOpen reports whether requests are currently being rejected
+func (b *CircuitBreaker) IsOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == breakerOpen && time.Now().Before(b.openUntil)
+}
+
+// RetryAt returns when the next probe request will be allowed, or the zero time
+// if the circuit is closed
+func (b *CircuitBreaker) RetryAt() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != breakerOpen {
+		return time.Time{}
+	}
+	return b.openUntil
+}
+
+// GetStats returns the breaker state for monitoring
+func (b *CircuitBreaker) GetStats() map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := map[string]any{
+		"state":                b.state.String(),
+		"consecutive_failures": b.failures,
+		"threshold":            b.threshold,
+		"cooldown_ms":          b.cooldown.Milliseconds(),
+		"open_count":           b.openCount,
+	}
+	if b.state == breakerOpen {
+		stats["open_until"] = b.openUntil.Unix()
+	}
+	return stats
+}
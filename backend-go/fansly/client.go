@@ -18,7 +18,7 @@ import (
 )
 
 const (
-	baseURL        = "https://apiv3.fansly.com/api/v1"
+	defaultBaseURL = "https://apiv3.fansly.com/api/v1"
 	defaultTimeout = 30 * time.Second
 	maxAccountIDs  = 100
 )
@@ -29,6 +29,8 @@ var ErrTagNotFound = errors.New("fansly: tag not found")
 type Client struct {
 	httpClient    *http.Client
 	globalLimiter *ratelimit.GlobalRateLimiter
+	breaker       *CircuitBreaker
+	baseURL       string
 	authToken     string
 	logger        *zap.Logger
 }
@@ -43,6 +45,8 @@ func NewClient() *Client {
 
 	return &Client{
 		httpClient: httpClient,
+		breaker:    NewCircuitBreaker(defaultBreakerThreshold, defaultBreakerCooldown, logger),
+		baseURL:    defaultBaseURL,
 		authToken:  getEnv("FANSLY_AUTH_TOKEN", ""),
 		logger:     logger,
 	}
@@ -53,6 +57,33 @@ func (c *Client) SetGlobalRateLimit(maxRequests int, windowSeconds int) {
 	c.globalLimiter = ratelimit.NewGlobalRateLimiter(maxRequests, windowSeconds, c.logger)
 }
 
+// GetRateLimitStats returns the global limiter statistics, or nil if no limiter is configured
+func (c *Client) GetRateLimitStats() map[string]any {
+	if c.globalLimiter == nil {
+		return nil
+	}
+	return c.globalLimiter.GetStats()
+}
+
+// SetCircuitBreaker replaces the circuit breaker with one using the given
+// consecutive failure threshold and initial cooldown
+func (c *Client) SetCircuitBreaker(threshold int, cooldown time.Duration) {
+	c.breaker = NewCircuitBreaker(threshold, cooldown, c.logger)
+}
+
+// CircuitBreaker returns the breaker shared by every request of this client
+func (c *Client) CircuitBreaker() *CircuitBreaker {
+	return c.breaker
+}
+
+// SetBaseURL overrides the Fansly API base URL, e.g. to point at a fake server
+func (c *Client) SetBaseURL(baseURL string) {
+	if baseURL == "" {
+		return
+	}
+	c.baseURL = strings.TrimRight(baseURL, "/")
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -60,15 +91,33 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-// doRequest performs an HTTP request with global rate limiting and retry logic
+// doRequest performs an HTTP request unless the circuit breaker is open, and
+// reports to the breaker whether Fansly answered
 func (c *Client) doRequest(ctx context.Context, url string) ([]byte, error) {
-	// Apply global rate limiting if configured
-	if c.globalLimiter != nil {
-		if err := c.globalLimiter.Wait(ctx); err != nil {
-			return nil, fmt.Errorf("rate limiter error: %w", err)
-		}
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
 	}
 
+	body, err := c.doRequestWithRetries(ctx, url)
+	switch {
+	case err == nil:
+		c.breaker.RecordSuccess()
+	case ctx.Err() != nil:
+		c.breaker.RecordAbort()
+	case errors.Is(err, ErrUnavailable):
+		c.breaker.RecordFailure()
+	default:
+		// Fansly answered, just not with what we asked for
+		c.breaker.RecordSuccess()
+	}
+
+	return body, err
+}
+
+// doRequestWithRetries performs an HTTP request with global rate limiting and retry logic.
+// Every attempt waits on the global limiter and reports its response back to it,
+// so a 429 slows down all callers rather than just the one that hit it.
+func (c *Client) doRequestWithRetries(ctx context.Context, url string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -95,6 +144,14 @@ func (c *Client) doRequest(ctx context.Context, url string) ([]byte, error) {
 	backoff := time.Second
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
+		// Apply global rate limiting if configured
+		if c.globalLimiter != nil {
+			if err := c.globalLimiter.Wait(ctx); err != nil {
+				return nil, fmt.Errorf("rate limiter error: %w", err)
+			}
+		}
+
+		countRequest(ctx)
 		resp, err = c.httpClient.Do(req.Clone(ctx))
 		if err != nil {
 			lastErr = err
@@ -112,7 +169,11 @@ func (c *Client) doRequest(ctx context.Context, url string) ([]byte, error) {
 					return nil, ctx.Err()
 				}
 			}
-			return nil, fmt.Errorf("request failed after %d attempts: %w", maxRetries+1, lastErr)
+			return nil, fmt.Errorf("%w: request failed after %d attempts: %w", ErrUnavailable, maxRetries+1, lastErr)
+		}
+
+		if c.globalLimiter != nil {
+			c.globalLimiter.ObserveResponse(resp.StatusCode, resp.Header)
 		}
 
 		if retryableStatuses[resp.StatusCode] && attempt < maxRetries {
@@ -124,14 +185,22 @@ func (c *Client) doRequest(ctx context.Context, url string) ([]byte, error) {
 				zap.Int("status", resp.StatusCode),
 				zap.Int("attempt", attempt+1))
 
-			// For rate limit errors, wait longer
+			wait := backoff
+			backoff *= 2
 			if resp.StatusCode == http.StatusTooManyRequests {
-				backoff = 30 * time.Second
+				if c.globalLimiter != nil {
+					// The limiter now blocks every caller until Retry-After; wait there
+					continue
+				}
+				if retryAfter, ok := ratelimit.ParseRetryAfter(resp.Header, time.Now()); ok {
+					wait = retryAfter
+				} else {
+					wait = 30 * time.Second
+				}
 			}
 
 			select {
-			case <-time.After(backoff):
-				backoff *= 2
+			case <-time.After(wait):
 				continue
 			case <-ctx.Done():
 				return nil, ctx.Err()
@@ -151,6 +220,11 @@ func (c *Client) doRequest(ctx context.Context, url string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	// Still rate limited after every retry: back off like an outage rather than
+	// treating it as a problem with this request
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("%w: API error: status=%d, body=%s", ErrUnavailable, resp.StatusCode, string(body))
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("API error: status=%d, body=%s", resp.StatusCode, string(body))
 	}
@@ -183,7 +257,7 @@ type TagResponseData struct {
 
 // GetTagWithContext fetches a single tag by name with context
 func (c *Client) GetTagWithContext(ctx context.Context, tagName string) (*TagResponseData, error) {
-	url := fmt.Sprintf("%s/contentdiscovery/media/tag?tag=%s&ngsw-bypass=true", baseURL, tagName)
+	url := fmt.Sprintf("%s/contentdiscovery/media/tag?tag=%s&ngsw-bypass=true", c.baseURL, tagName)
 
 	body, err := c.doRequest(ctx, url)
 	if err != nil {
@@ -281,7 +355,7 @@ func (c *Client) GetSuggestionsData(ctx context.Context, tagIDs []string, before
 	}
 
 	url := fmt.Sprintf("%s/contentdiscovery/media/suggestionsnew?before=%s&after=%s&tagIds=%s&limit=%d&offset=%d&ngsw-bypass=true",
-		baseURL, before, after, tagIDsStr.String(), limit, offset)
+		c.baseURL, before, after, tagIDsStr.String(), limit, offset)
 
 	body, err := c.doRequest(ctx, url)
 	if err != nil {
@@ -332,7 +406,7 @@ func (c *Client) getAccountsChunkWithContext(ctx context.Context, accountIDs []s
 		idsParam.WriteString(id)
 	}
 
-	url := fmt.Sprintf("%s/account?ids=%s&ngsw-bypass=true", baseURL, idsParam.String())
+	url := fmt.Sprintf("%s/account?ids=%s&ngsw-bypass=true", c.baseURL, idsParam.String())
 
 	body, err := c.doRequest(ctx, url)
 	if err != nil {
@@ -356,7 +430,7 @@ func (c *Client) getAccountsChunkWithContext(ctx context.Context, accountIDs []s
 
 // GetAccountByUsername fetches a single account by username
 func (c *Client) GetAccountByUsername(ctx context.Context, username string) (*FanslyAccount, error) {
-	url := fmt.Sprintf("%s/account?usernames=%s&ngsw-bypass=true", baseURL, username)
+	url := fmt.Sprintf("%s/account?usernames=%s&ngsw-bypass=true", c.baseURL, username)
 
 	body, err := c.doRequest(ctx, url)
 	if err != nil {
@@ -0,0 +1,31 @@
+package fansly_test
+
+import (
+	"context"
+	"errors"
+	"ftoolbox/fansly"
+	"ftoolbox/fansly/fakefansly"
+	"testing"
+)
+
+func TestRateLimitedIsUnavailable(t *testing.T) {
+	server := fakefansly.New()
+	server.Start()
+	defer server.Close()
+	server.AddTag(fansly.FanslyTag{ID: "1", Tag: "feet"})
+	server.Throttle(1000, 0)
+
+	client := fansly.NewClient()
+	client.SetBaseURL(server.BaseURL())
+
+	_, err := client.GetTagWithContext(context.Background(), "feet")
+	if !errors.Is(err, fansly.ErrUnavailable) {
+		t.Fatalf("GetTagWithContext() = %v, want ErrUnavailable", err)
+	}
+	if got := server.RequestCount("/contentdiscovery/media/tag"); got != 4 {
+		t.Errorf("tag lookups = %d, want 4 with retries", got)
+	}
+	if got := client.CircuitBreaker().GetStats()["consecutive_failures"]; got != 1 {
+		t.Errorf("breaker failures = %v, want 1", got)
+	}
+}
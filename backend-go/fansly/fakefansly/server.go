@@ -0,0 +1,285 @@
+// Package fakefansly provides a scripted, in-memory stand-in for the Fansly API.
+// Point a fansly.Client at Server.BaseURL() to exercise workers and handlers
+// without touching the real service.
+package fakefansly
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ftoolbox/fansly"
+)
+
+// APIPrefix is the path prefix the real Fansly API is served under
+const APIPrefix = "/api/v1"
+
+// Fixtures describes the data served by the fake server
+type Fixtures struct {
+	Tags []fansly.FanslyTag `json:"tags"`
+	// Suggestions are keyed by the comma-separated tagIds query parameter and listed
+	// newest first. They are paged with before (numeric suggestion ID), offset and limit.
+	Suggestions map[string]fansly.SuggestionsResponseData `json:"suggestions"`
+	Accounts    []fansly.FanslyAccount                    `json:"accounts"`
+}
+
+// LoadFixtures reads fixtures from a JSON file
+func LoadFixtures(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+
+	var fixtures Fixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("failed to decode fixtures: %w", err)
+	}
+
+	return &fixtures, nil
+}
+
+// Server is a fake Fansly HTTP API
+type Server struct {
+	mu          sync.RWMutex
+	tags        map[string]fansly.FanslyTag
+	suggestions map[string]fansly.SuggestionsResponseData
+	accounts    map[string]fansly.FanslyAccount
+	requests    map[string]int
+	throttled   int
+	retryAfter  time.Duration
+	httpServer  *httptest.Server
+}
+
+// New creates an empty fake server. Call Start to serve it on a local port,
+// or mount Handler in an existing http.Server.
+func New() *Server {
+	return &Server{
+		tags:        make(map[string]fansly.FanslyTag),
+		suggestions: make(map[string]fansly.SuggestionsResponseData),
+		accounts:    make(map[string]fansly.FanslyAccount),
+		requests:    make(map[string]int),
+	}
+}
+
+// NewWithFixtures creates a fake server preloaded with fixtures
+func NewWithFixtures(fixtures *Fixtures) *Server {
+	s := New()
+	if fixtures == nil {
+		return s
+	}
+
+	for _, tag := range fixtures.Tags {
+		s.AddTag(tag)
+	}
+	for tagIDs, data := range fixtures.Suggestions {
+		s.SetSuggestions(strings.Split(tagIDs, ","), data)
+	}
+	for _, account := range fixtures.Accounts {
+		s.AddAccount(account)
+	}
+
+	return s
+}
+
+// Start serves the fake API on a random local port
+func (s *Server) Start() {
+	s.httpServer = httptest.NewServer(s.Handler())
+}
+
+// Close shuts down a server started with Start
+func (s *Server) Close() {
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+}
+
+// BaseURL returns the URL to pass to fansly.Client.SetBaseURL
+func (s *Server) BaseURL() string {
+	if s.httpServer == nil {
+		return ""
+	}
+	return s.httpServer.URL + APIPrefix
+}
+
+// AddTag adds or replaces a tag, keyed by its lowercased name
+func (s *Server) AddTag(tag fansly.FanslyTag) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tags[strings.ToLower(tag.Tag)] = tag
+}
+
+// RemoveTag makes a tag return "not found" on subsequent lookups
+func (s *Server) RemoveTag(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tags, strings.ToLower(name))
+}
+
+// SetSuggestions scripts the suggestions response for a set of tag IDs
+func (s *Server) SetSuggestions(tagIDs []string, data fansly.SuggestionsResponseData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.suggestions[strings.Join(tagIDs, ",")] = data
+}
+
+// AddAccount adds or replaces an account
+func (s *Server) AddAccount(account fansly.FanslyAccount) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts[account.ID] = account
+}
+
+// RemoveAccount makes an account disappear from lookups
+func (s *Server) RemoveAccount(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.accounts, id)
+}
+
+// Throttle makes the next n requests fail with 429 and the given Retry-After
+func (s *Server) Throttle(n int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.throttled = n
+	s.retryAfter = retryAfter
+}
+
+// RequestCount returns how many requests were served for a path (without APIPrefix)
+func (s *Server) RequestCount(path string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.requests[path]
+}
+
+// Handler returns the HTTP handler serving the fake API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+APIPrefix+"/contentdiscovery/media/tag", s.handleTag)
+	mux.HandleFunc("GET "+APIPrefix+"/contentdiscovery/media/suggestionsnew", s.handleSuggestions)
+	mux.HandleFunc("GET "+APIPrefix+"/account", s.handleAccounts)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[strings.TrimPrefix(r.URL.Path, APIPrefix)]++
+		throttle := s.throttled > 0
+		if throttle {
+			s.throttled--
+		}
+		retryAfter := s.retryAfter
+		s.mu.Unlock()
+
+		if throttle {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleTag(w http.ResponseWriter, r *http.Request) {
+	name := strings.ToLower(r.URL.Query().Get("tag"))
+
+	s.mu.RLock()
+	tag, ok := s.tags[name]
+	s.mu.RUnlock()
+
+	if !ok {
+		writeJSON(w, fansly.TagResponseData{})
+		return
+	}
+
+	writeJSON(w, fansly.TagResponseData{MediaOfferSuggestionTag: &tag})
+}
+
+func (s *Server) handleSuggestions(w http.ResponseWriter, r *http.Request) {
+	tagIDs := r.URL.Query().Get("tagIds")
+
+	s.mu.RLock()
+	data, ok := s.suggestions[tagIDs]
+	s.mu.RUnlock()
+
+	if !ok {
+		writeJSON(w, fansly.SuggestionsResponseData{})
+		return
+	}
+
+	writeJSON(w, pageSuggestions(data, r.URL.Query()))
+}
+
+// pageSuggestions applies the before cursor, offset and limit query parameters
+func pageSuggestions(data fansly.SuggestionsResponseData, query map[string][]string) fansly.SuggestionsResponseData {
+	get := func(key string) string {
+		if values := query[key]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	suggestions := data.MediaOfferSuggestions
+	if before, err := strconv.ParseInt(get("before"), 10, 64); err == nil && before > 0 {
+		filtered := make([]fansly.MediaOfferSuggestion, 0, len(suggestions))
+		for _, suggestion := range suggestions {
+			if id, err := strconv.ParseInt(suggestion.ID, 10, 64); err == nil && id < before {
+				filtered = append(filtered, suggestion)
+			}
+		}
+		suggestions = filtered
+	}
+
+	if offset, err := strconv.Atoi(get("offset")); err == nil && offset > 0 {
+		suggestions = suggestions[min(offset, len(suggestions)):]
+	}
+	if limit, err := strconv.Atoi(get("limit")); err == nil && limit > 0 && limit < len(suggestions) {
+		suggestions = suggestions[:limit]
+	}
+
+	data.MediaOfferSuggestions = suggestions
+	return data
+}
+
+func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	accounts := make([]fansly.FanslyAccount, 0)
+	if ids := query.Get("ids"); ids != "" {
+		for _, id := range strings.Split(ids, ",") {
+			if account, ok := s.accounts[id]; ok {
+				accounts = append(accounts, account)
+			}
+		}
+	} else if usernames := query.Get("usernames"); usernames != "" {
+		for _, username := range strings.Split(usernames, ",") {
+			for _, account := range s.accounts {
+				if strings.EqualFold(account.Username, username) {
+					accounts = append(accounts, account)
+					break
+				}
+			}
+		}
+	}
+
+	writeJSON(w, accounts)
+}
+
+func writeJSON(w http.ResponseWriter, response any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Success  bool `json:"success"`
+		Response any  `json:"response"`
+	}{
+		Success:  true,
+		Response: response,
+	})
+}
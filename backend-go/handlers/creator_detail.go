@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"errors"
+	"ftoolbox/models"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// creatorPercentileMetrics are the metrics a creator's detail places within the
+// ranked creators, with the columns their counts are scanned into
+var creatorPercentileMetrics = map[string]string{
+	"Followers":             "followers",
+	"MediaLikes":            "media_likes",
+	"PostLikes":             "post_likes",
+	"MediaLikesPerFollower": "media_likes_per_follower",
+	"PostLikesPerFollower":  "post_likes_per_follower",
+	"MediaLikesPerMedia":    "media_likes_per_media",
+}
+
+// GetCreator returns the full profile of a creator by ID
+func (h *CreatorHandler) GetCreator(c *fiber.Ctx) error {
+	return h.respondCreatorDetail(c, h.db.Where("id = ?", c.Params("id")))
+}
+
+// GetCreatorByUsername returns the full profile of a creator by exact username. If
+// several stored creators had that username, the live, most recently updated wins.
+func (h *CreatorHandler) GetCreatorByUsername(c *fiber.Ctx) error {
+	username, err := url.PathUnescape(c.Params("username"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid username"})
+	}
+
+	return h.respondCreatorDetail(c, h.db.
+		Where("username = ?", strings.TrimLeft(strings.TrimSpace(username), "@")).
+		Order("is_deleted ASC, updated_at DESC"))
+}
+
+// respondCreatorDetail answers with the creator the query finds: its record with
+// engagement ratios and growth over historyStartDate..historyEndDate (by default the
+// last 7 days), history in that range downsampled to maxPoints, rank movement and
+// daily rank history, and the percentile of its metrics among ranked creators as of
+// historyEndDate.
+func (h *CreatorHandler) respondCreatorDetail(c *fiber.Ctx, query *gorm.DB) error {
+	startDate := parseHistoryDate(c.Query("historyStartDate"))
+	endDate := parseHistoryDate(c.Query("historyEndDate"))
+	maxPoints := parseHistoryPoints(c)
+
+	var creator models.Creator
+	if err := query.First(&creator).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Creator not found"})
+		}
+		zap.L().Error("Failed to fetch creator", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch creator"})
+	}
+	creatorIDs := []string{creator.ID}
+
+	snapshots, err := h.loadCreatorSnapshotsForRange(creatorIDs, endDate)
+	if err != nil {
+		zap.L().Error("Failed to fetch creator snapshots", zap.String("creator", creator.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch creator snapshots"})
+	}
+	rangeStarts, err := loadCreatorRangeStarts(h.db, creatorIDs, newCreatorRange(startDate, endDate))
+	if err != nil {
+		zap.L().Error("Failed to fetch creator range starts", zap.String("creator", creator.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch creator snapshots"})
+	}
+
+	historyByCreator, err := h.loadCreatorHistoryByCreator(creatorIDs, startDate, endDate)
+	if err != nil {
+		zap.L().Error("Failed to fetch creator history", zap.String("creator", creator.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch creator history"})
+	}
+	history := historyByCreator[creator.ID]
+	sampled := downsampleHistory(history, maxPoints, func(point models.CreatorHistory) time.Time {
+		return point.CreatedAt
+	})
+
+	now := time.Now()
+	rankHistory, err := loadRankHistory(h.db, "creator_ranks_daily", "creator_id", creator.ID, startDate, endDate)
+	if err != nil {
+		zap.L().Error("Failed to fetch creator rank history", zap.String("creator", creator.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch creator rank history"})
+	}
+	recentRanks, err := loadRankHistory(h.db, "creator_ranks_daily", "creator_id", creator.ID,
+		ptr(now.AddDate(0, 0, -rankMovementDays[len(rankMovementDays)-1]-1)), nil)
+	if err != nil {
+		zap.L().Error("Failed to fetch creator rank history", zap.String("creator", creator.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch creator rank history"})
+	}
+
+	percentiles, err := h.loadCreatorPercentiles(creator.ID, endDate)
+	if err != nil {
+		zap.L().Error("Failed to compute creator percentiles", zap.String("creator", creator.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to compute creator percentiles"})
+	}
+
+	return c.JSON(fiber.Map{
+		"creator": buildCreatorWithHistory(creator, snapshots, sampled, rangeStarts),
+		"rank": fiber.Map{
+			"current":  creator.Rank,
+			"movement": rankMovement(creator.Rank, recentRanks, now),
+			"history":  rankHistory,
+		},
+		"historyPoints": fiber.Map{
+			"total":    len(history),
+			"returned": len(sampled),
+		},
+		"percentiles": percentiles,
+	})
+}
+
+// loadCreatorPercentiles places the creator's metrics among the ranked creators, as
+// the percentage of them with a lower value. The creator's own values come from the
+// same SQL as the others'. With an endDate every creator, this one included, is
+// placed by its latest snapshot up to then.
+func (h *CreatorHandler) loadCreatorPercentiles(creatorID string, endDate *time.Time) (fiber.Map, error) {
+	exprs := creatorMetricExprs(nil, endDate)
+
+	selects := []string{"COUNT(*) AS total"}
+	var ownSelects []string
+	for _, name := range slices.Sorted(maps.Keys(creatorPercentileMetrics)) {
+		column := creatorPercentileMetrics[name]
+		ownSelects = append(ownSelects, exprs[name].SQL+" AS "+column)
+		selects = append(selects, "COALESCE(SUM(CASE WHEN "+exprs[name].SQL+" < own."+column+" THEN 1 ELSE 0 END), 0) AS "+
+			column)
+	}
+
+	query := h.db.Model(&models.Creator{})
+	own := "SELECT " + strings.Join(ownSelects, ", ") + " FROM creators"
+	var ownArgs []any
+	if endDate != nil {
+		query = query.Joins(latestCreatorSnapshotJoinForCreatorsClause(), *endDate)
+		own += " " + latestCreatorSnapshotJoinForCreatorsClause()
+		ownArgs = append(ownArgs, *endDate)
+	}
+	own += " WHERE creators.id = ?"
+	ownArgs = append(ownArgs, creatorID)
+
+	var counts struct {
+		Total                 int64
+		Followers             int64
+		MediaLikes            int64
+		PostLikes             int64
+		MediaLikesPerFollower int64
+		PostLikesPerFollower  int64
+		MediaLikesPerMedia    int64
+	}
+	if err := query.
+		Joins("CROSS JOIN ("+own+") AS own", ownArgs...).
+		Select(strings.Join(selects, ", ")).
+		Where("creators.rank IS NOT NULL").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	percentile := func(below int64) float64 {
+		if counts.Total == 0 {
+			return 0
+		}
+		return float64(below) * 100 / float64(counts.Total)
+	}
+	return fiber.Map{
+		"followers":             percentile(counts.Followers),
+		"mediaLikes":            percentile(counts.MediaLikes),
+		"postLikes":             percentile(counts.PostLikes),
+		"mediaLikesPerFollower": percentile(counts.MediaLikesPerFollower),
+		"postLikesPerFollower":  percentile(counts.PostLikesPerFollower),
+		"mediaLikesPerMedia":    percentile(counts.MediaLikesPerMedia),
+	}, nil
+}
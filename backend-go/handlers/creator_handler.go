@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"ftoolbox/fansly"
 	"ftoolbox/models"
+	"ftoolbox/ratelimit"
+	"ftoolbox/services"
 	"ftoolbox/utils"
 	"strconv"
 	"strings"
@@ -15,13 +18,15 @@ import (
 
 type CreatorHandler struct {
 	db           *gorm.DB
-	fanslyClient *fansly.Client
+	fanslyClient fansly.API
+	creators     *services.CreatorService
 }
 
-func NewCreatorHandler(db *gorm.DB, fanslyClient *fansly.Client) *CreatorHandler {
+func NewCreatorHandler(db *gorm.DB, fanslyClient fansly.API) *CreatorHandler {
 	return &CreatorHandler{
 		db:           db,
 		fanslyClient: fanslyClient,
+		creators:     services.NewCreatorService(db),
 	}
 }
 
@@ -48,11 +53,18 @@ type CreatorWithHistory struct {
 	VideoCount        int64                 `json:"videoCount"`
 	Rank              *int                  `json:"rank"`
 	LastCheckedAt     *int64                `json:"lastCheckedAt"`
+	NextRefreshAt     *int64                `json:"nextRefreshAt"`
+	IsRequested       bool                  `json:"isRequested"`
 	IsDeleted         bool                  `json:"isDeleted"`
 	DeletedDetectedAt *int64                `json:"deletedDetectedAt"`
 	CreatedAt         int64                 `json:"createdAt"`
 	UpdatedAt         int64                 `json:"updatedAt"`
 	History           []CreatorHistoryPoint `json:"history,omitempty"`
+	// Engagement ratios at the range end
+	MediaLikesPerFollower float64        `json:"mediaLikesPerFollower"`
+	PostLikesPerFollower  float64        `json:"postLikesPerFollower"`
+	MediaLikesPerMedia    float64        `json:"mediaLikesPerMedia"`
+	RangeChange           *creatorChange `json:"rangeChange,omitempty"`
 }
 
 type creatorMetrics struct {
@@ -67,7 +79,9 @@ func (h *CreatorHandler) GetCreators(c *fiber.Ctx) error {
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
 	search := c.Query("search")
+	sortBy := sanitizeCreatorSortBy(strings.ToLower(c.Query("sortBy", "rank")))
 	sortOrder := strings.ToLower(c.Query("sortOrder", "asc"))
+	deleted := c.Query("deleted")
 	includeHistory := c.Query("includeHistory") == "true"
 	historyStartDate := c.Query("historyStartDate")
 	historyEndDate := c.Query("historyEndDate")
@@ -86,14 +100,50 @@ func (h *CreatorHandler) GetCreators(c *fiber.Ctx) error {
 	startDate := parseHistoryDate(historyStartDate)
 	endDate := parseHistoryDate(historyEndDate)
 
+	bounds, err := parseRangeBounds(c, creatorRangeMetrics)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	changeRange := resolveCreatorRange(startDate, endDate, sortBy, bounds)
+	metricExprs := creatorMetricExprs(changeRange, endDate)
+
 	var creators []models.Creator
-	query := applyCreatorSearch(h.db.Model(&models.Creator{}), search).Where("rank IS NOT NULL")
+	query := applyCreatorSearch(h.db.Model(&models.Creator{}), search)
+	switch deleted {
+	case "":
+		query = query.Where("creators.rank IS NOT NULL")
+	case "true", "false":
+		query = query.Where("creators.is_deleted = ?", deleted == "true")
+		// Deleted creators are unranked, so only live ones need a rank
+		if deleted == "false" {
+			query = query.Where("creators.rank IS NOT NULL")
+		}
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "deleted must be true or false"})
+	}
+
+	// Metrics at a past range end come from the latest snapshot before it
+	if endDate != nil && (sortBy != "rank" || len(bounds) > 0) {
+		query = query.Joins(latestCreatorSnapshotJoinForCreatorsClause(), *endDate)
+	}
+	if changeRange != nil {
+		query = changeRange.join(query)
+	}
+	query = applyRangeBounds(query, bounds, metricExprs)
 
 	var total int64
 	query.Count(&total)
 
 	needsHistory := includeHistory
-	query = query.Order("rank " + sortOrder).Limit(limit).Offset(offset)
+	if sortBy == "rank" {
+		// Unranked (deleted) creators come last, biggest first
+		query = query.Order("creators.rank IS NULL").
+			Order("creators.rank " + sortOrder).
+			Order("creators.followers DESC")
+	} else {
+		query = query.Order(orderByExpr(metricExprs[sortBy], sortOrder, "creators.rank ASC"))
+	}
+	query = query.Limit(limit).Offset(offset)
 
 	if err := query.Find(&creators).Error; err != nil {
 		zap.L().Error("Failed to fetch creators", zap.Error(err))
@@ -107,6 +157,15 @@ func (h *CreatorHandler) GetCreators(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch creator snapshots"})
 	}
 
+	var rangeStarts map[string]models.CreatorHistory
+	if changeRange != nil {
+		rangeStarts, err = loadCreatorRangeStarts(h.db, creatorIDs, changeRange)
+		if err != nil {
+			zap.L().Error("Failed to fetch creator range starts", zap.Error(err))
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch creator snapshots"})
+		}
+	}
+
 	if needsHistory {
 		historyByCreator, err := h.loadCreatorHistoryByCreator(creatorIDs, startDate, endDate)
 		if err != nil {
@@ -115,13 +174,13 @@ func (h *CreatorHandler) GetCreators(c *fiber.Ctx) error {
 		}
 
 		return c.JSON(fiber.Map{
-			"creators":   buildCreatorsWithHistory(creators, creatorSnapshots, historyByCreator),
+			"creators":   buildCreatorsWithHistory(creators, creatorSnapshots, historyByCreator, rangeStarts),
 			"pagination": buildPagination(page, limit, total),
 		})
 	}
 
 	return c.JSON(fiber.Map{
-		"creators":   buildCreatorData(creators, creatorSnapshots),
+		"creators":   buildCreatorData(creators, creatorSnapshots, rangeStarts),
 		"pagination": buildPagination(page, limit, total),
 	})
 }
@@ -182,6 +241,16 @@ func (h *CreatorHandler) RequestCreator(c *fiber.Ctx) error {
 	// Check if creator already exists
 	var existingCreator models.Creator
 	if err := h.db.Where("username = ?", req.Username).First(&existingCreator).Error; err == nil {
+		// Requested creators are refreshed more often
+		if !existingCreator.IsRequested {
+			if err := h.db.Model(&existingCreator).UpdateColumns(map[string]any{
+				"is_requested":    true,
+				"next_refresh_at": time.Now(),
+			}).Error; err != nil {
+				zap.L().Error("Failed to mark creator as requested", zap.String("username", existingCreator.Username), zap.Error(err))
+			}
+		}
+
 		// Return existing creator
 		return c.JSON(fiber.Map{
 			"message": "Creator is already being tracked",
@@ -189,9 +258,14 @@ func (h *CreatorHandler) RequestCreator(c *fiber.Ctx) error {
 		})
 	}
 
-	// Immediately try to fetch creator data from Fansly
-	fanslyAccount, err := h.fanslyClient.GetAccountByUsername(c.Context(), req.Username)
+	// Immediately try to fetch creator data from Fansly, ahead of background work
+	ctx := ratelimit.WithPriority(c.Context(), ratelimit.PriorityInteractive)
+	fanslyAccount, err := h.fanslyClient.GetAccountByUsername(ctx, req.Username)
 
+	if errors.Is(err, fansly.ErrUnavailable) {
+		zap.L().Warn("Fansly unavailable while requesting creator", zap.Error(err))
+		return c.Status(503).JSON(fiber.Map{"error": "Fansly is currently unavailable, please try again later"})
+	}
 	if err != nil || fanslyAccount == nil {
 		if err != nil {
 			zap.L().Error("Failed to fetch creator data from Fansly", zap.Error(err))
@@ -201,37 +275,17 @@ func (h *CreatorHandler) RequestCreator(c *fiber.Ctx) error {
 		return c.Status(404).JSON(fiber.Map{"error": "Creator not found on Fansly"})
 	}
 
-	// Insert creator into database
-	newCreator := models.Creator{
-		ID:            fanslyAccount.ID,
-		Username:      fanslyAccount.Username,
-		DisplayName:   &fanslyAccount.DisplayName,
-		MediaLikes:    fanslyAccount.AccountMediaLikes,
-		PostLikes:     fanslyAccount.PostLikes,
-		Followers:     fanslyAccount.FollowCount,
-		ImageCount:    fanslyAccount.TimelineStats.ImageCount,
-		VideoCount:    fanslyAccount.TimelineStats.VideoCount,
-		LastCheckedAt: &[]time.Time{time.Now()}[0],
-	}
-
-	if err := h.db.Create(&newCreator).Error; err != nil {
+	// Insert creator into database with its initial history point
+	stored, err := h.creators.Store([]services.CreatorEntry{{
+		Account:     fanslyAccount,
+		NextRefresh: time.Now().Add(services.InitialRefreshDelay),
+		Requested:   true,
+	}})
+	if err != nil || len(stored) == 0 {
 		zap.L().Error("Failed to create creator", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create creator"})
 	}
-
-	// Insert initial history record
-	history := models.CreatorHistory{
-		CreatorID:  newCreator.ID,
-		MediaLikes: newCreator.MediaLikes,
-		PostLikes:  newCreator.PostLikes,
-		Followers:  newCreator.Followers,
-		ImageCount: newCreator.ImageCount,
-		VideoCount: newCreator.VideoCount,
-	}
-
-	if err := h.db.Create(&history).Error; err != nil {
-		zap.L().Error("Failed to create creator history", zap.Error(err))
-	}
+	newCreator := stored[0]
 
 	// Calculate ranks after adding the new creator
 	if err := utils.CalculateCreatorRanks(h.db); err != nil {
@@ -260,7 +314,7 @@ func applyCreatorSearch(query *gorm.DB, search string) *gorm.DB {
 		return query
 	}
 
-	return query.Where("username LIKE ? OR display_name LIKE ?", "%"+search+"%", "%"+search+"%")
+	return query.Where("creators.username LIKE ? OR creators.display_name LIKE ?", "%"+search+"%", "%"+search+"%")
 }
 
 func collectCreatorIDs(creators []models.Creator) []string {
@@ -324,8 +378,9 @@ func buildCreatorWithHistory(
 	creator models.Creator,
 	snapshots map[string]models.CreatorHistory,
 	history []models.CreatorHistory,
+	rangeStarts map[string]models.CreatorHistory,
 ) CreatorWithHistory {
-	response := buildCreatorSummary(creator, snapshots)
+	response := buildCreatorSummary(creator, snapshots, rangeStarts)
 	response.History = buildCreatorHistoryPoints(history)
 	return response
 }
@@ -334,22 +389,27 @@ func buildCreatorsWithHistory(
 	creators []models.Creator,
 	snapshots map[string]models.CreatorHistory,
 	historyByCreator map[string][]models.CreatorHistory,
+	rangeStarts map[string]models.CreatorHistory,
 ) []CreatorWithHistory {
 	creatorsWithHistory := make([]CreatorWithHistory, 0, len(creators))
 	for _, creator := range creators {
 		creatorsWithHistory = append(
 			creatorsWithHistory,
-			buildCreatorWithHistory(creator, snapshots, historyByCreator[creator.ID]),
+			buildCreatorWithHistory(creator, snapshots, historyByCreator[creator.ID], rangeStarts),
 		)
 	}
 
 	return creatorsWithHistory
 }
 
-func buildCreatorData(creators []models.Creator, snapshots map[string]models.CreatorHistory) []map[string]any {
+func buildCreatorData(
+	creators []models.Creator,
+	snapshots map[string]models.CreatorHistory,
+	rangeStarts map[string]models.CreatorHistory,
+) []map[string]any {
 	creatorsData := make([]map[string]any, len(creators))
 	for i, creator := range creators {
-		response := buildCreatorSummary(creator, snapshots)
+		response := buildCreatorSummary(creator, snapshots, rangeStarts)
 		creatorsData[i] = map[string]any{
 			"id":                response.ID,
 			"username":          response.Username,
@@ -361,10 +421,19 @@ func buildCreatorData(creators []models.Creator, snapshots map[string]models.Cre
 			"videoCount":        response.VideoCount,
 			"rank":              response.Rank,
 			"lastCheckedAt":     response.LastCheckedAt,
+			"nextRefreshAt":     response.NextRefreshAt,
+			"isRequested":       response.IsRequested,
 			"isDeleted":         response.IsDeleted,
 			"deletedDetectedAt": response.DeletedDetectedAt,
 			"createdAt":         response.CreatedAt,
 			"updatedAt":         response.UpdatedAt,
+
+			"mediaLikesPerFollower": response.MediaLikesPerFollower,
+			"postLikesPerFollower":  response.PostLikesPerFollower,
+			"mediaLikesPerMedia":    response.MediaLikesPerMedia,
+		}
+		if response.RangeChange != nil {
+			creatorsData[i]["rangeChange"] = response.RangeChange
 		}
 	}
 
@@ -374,9 +443,16 @@ func buildCreatorData(creators []models.Creator, snapshots map[string]models.Cre
 func buildCreatorSummary(
 	creator models.Creator,
 	snapshots map[string]models.CreatorHistory,
+	rangeStarts map[string]models.CreatorHistory,
 ) CreatorWithHistory {
 	metrics := buildCreatorMetrics(creator, snapshots)
 
+	var rangeChange *creatorChange
+	if rangeStarts != nil {
+		start, ok := rangeStarts[creator.ID]
+		rangeChange = ptr(buildCreatorChange(metrics, start, ok))
+	}
+
 	return CreatorWithHistory{
 		ID:                creator.ID,
 		Username:          creator.Username,
@@ -388,10 +464,17 @@ func buildCreatorSummary(
 		VideoCount:        metrics.VideoCount,
 		Rank:              creator.Rank,
 		LastCheckedAt:     timeToUnixPtr(creator.LastCheckedAt),
+		NextRefreshAt:     timeToUnixPtr(creator.NextRefreshAt),
+		IsRequested:       creator.IsRequested,
 		IsDeleted:         creator.IsDeleted,
 		DeletedDetectedAt: timeToUnixPtr(creator.DeletedDetectedAt),
 		CreatedAt:         creator.CreatedAt.Unix(),
 		UpdatedAt:         creator.UpdatedAt.Unix(),
+
+		MediaLikesPerFollower: utils.CalculateRatio(metrics.MediaLikes, metrics.Followers),
+		PostLikesPerFollower:  utils.CalculateRatio(metrics.PostLikes, metrics.Followers),
+		MediaLikesPerMedia:    utils.CalculateRatio(metrics.MediaLikes, metrics.ImageCount+metrics.VideoCount),
+		RangeChange:           rangeChange,
 	}
 }
 
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"ftoolbox/database/testdb"
+	"ftoolbox/fansly"
+	"ftoolbox/models"
+	"ftoolbox/services"
+	"ftoolbox/utils"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGetCreatorsDeletedFilter(t *testing.T) {
+	db := testdb.Open(t)
+	next := time.Now().Add(time.Hour)
+	if _, err := services.NewCreatorService(db).Store([]services.CreatorEntry{
+		{Account: &fansly.FanslyAccount{ID: "10", Username: "alice", FollowCount: 100}, NextRefresh: next},
+		{Account: &fansly.FanslyAccount{ID: "11", Username: "bob", FollowCount: 50}, NextRefresh: next},
+		{Account: &fansly.FanslyAccount{ID: "12", Username: "carol", FollowCount: 200}, NextRefresh: next},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.Creator{}).Where("id = ?", "12").Update("is_deleted", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.CalculateCreatorRanks(db); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Get("/creators", NewCreatorHandler(db, nil).GetCreators)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"alice", "bob"}},
+		{"?deleted=false", []string{"alice", "bob"}},
+		{"?deleted=true", []string{"carol"}},
+		{"?deleted=true&sortBy=followers&sortOrder=desc", []string{"carol"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", "/creators"+tt.query, nil), -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+
+			var body struct {
+				Creators []CreatorWithHistory `json:"creators"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			var usernames []string
+			for _, creator := range body.Creators {
+				usernames = append(usernames, creator.Username)
+			}
+			if !slices.Equal(usernames, tt.want) {
+				t.Errorf("creators = %q, want %q", usernames, tt.want)
+			}
+		})
+	}
+}
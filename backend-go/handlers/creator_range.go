@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"ftoolbox/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultCreatorChangeRange is the range growth is measured over when no historyStartDate is given
+const defaultCreatorChangeRange = 7 * 24 * time.Hour
+
+// creatorCountColumns maps the creator counts to their columns in creators and creator_history
+var creatorCountColumns = map[string]string{
+	"Followers":  "followers",
+	"MediaLikes": "media_likes",
+	"PostLikes":  "post_likes",
+	"ImageCount": "image_count",
+	"VideoCount": "video_count",
+}
+
+// creatorRatioMetrics are the engagement ratios, as numerator and denominator counts
+var creatorRatioMetrics = map[string][2]string{
+	"MediaLikesPerFollower": {"MediaLikes", "Followers"},
+	"PostLikesPerFollower":  {"PostLikes", "Followers"},
+	"MediaLikesPerMedia":    {"MediaLikes", "MediaCount"},
+}
+
+// creatorRangeMetrics are the metrics GetCreators sorts by and filters with min<Name>/max<Name>
+var creatorRangeMetrics = func() []string {
+	var names []string
+	for _, count := range []string{"Followers", "MediaLikes", "PostLikes", "ImageCount", "VideoCount"} {
+		names = append(names, count, count+"Change", count+"ChangePercent")
+	}
+	return append(names, "MediaLikesPerFollower", "PostLikesPerFollower", "MediaLikesPerMedia")
+}()
+
+// creatorRange measures creator metrics at the end of the history range, and growth
+// since the oldest history point inside it
+type creatorRange struct {
+	Start time.Time
+	End   *time.Time // nil means now
+}
+
+// creatorChange is a creator's growth over a creatorRange
+type creatorChange struct {
+	FollowersChange         int64   `json:"followersChange"`
+	FollowersChangePercent  float64 `json:"followersChangePercent"`
+	MediaLikesChange        int64   `json:"mediaLikesChange"`
+	MediaLikesChangePercent float64 `json:"mediaLikesChangePercent"`
+	PostLikesChange         int64   `json:"postLikesChange"`
+	PostLikesChangePercent  float64 `json:"postLikesChangePercent"`
+	ImageCountChange        int64   `json:"imageCountChange"`
+	ImageCountChangePercent float64 `json:"imageCountChangePercent"`
+	VideoCountChange        int64   `json:"videoCountChange"`
+	VideoCountChangePercent float64 `json:"videoCountChangePercent"`
+}
+
+// sanitizeCreatorSortBy maps a lowercased sortBy to its metric name, or "rank"
+func sanitizeCreatorSortBy(sortBy string) string {
+	for _, name := range creatorRangeMetrics {
+		if strings.ToLower(name) == sortBy {
+			return name
+		}
+	}
+	return "rank"
+}
+
+// usesCreatorChange reports whether a metric is growth over the range
+func usesCreatorChange(name string) bool {
+	return strings.HasSuffix(name, "Change") || strings.HasSuffix(name, "ChangePercent")
+}
+
+// resolveCreatorRange returns the range growth is measured over, or nil if the
+// request neither gives a range start nor sorts or filters by growth
+func resolveCreatorRange(startDate, endDate *time.Time, sortBy string, bounds map[string]rangeBound) *creatorRange {
+	usesChange := usesCreatorChange(sortBy)
+	for name := range bounds {
+		if usesCreatorChange(name) {
+			usesChange = true
+		}
+	}
+	if startDate == nil && !usesChange {
+		return nil
+	}
+	return newCreatorRange(startDate, endDate)
+}
+
+// newCreatorRange is the range from startDate, or defaultCreatorChangeRange before
+// its end, to endDate or now
+func newCreatorRange(startDate, endDate *time.Time) *creatorRange {
+	end := time.Now()
+	if endDate != nil {
+		end = *endDate
+	}
+	start := end.Add(-defaultCreatorChangeRange)
+	if startDate != nil {
+		start = *startDate
+	}
+	return &creatorRange{Start: start, End: endDate}
+}
+
+// join adds the oldest history point inside the range as creator_range_start
+func (r *creatorRange) join(query *gorm.DB) *gorm.DB {
+	end := time.Now()
+	if r.End != nil {
+		end = *r.End
+	}
+
+	return query.Joins("LEFT JOIN creator_history AS creator_range_start ON creator_range_start.id = ("+
+		"SELECT ch.id FROM creator_history AS ch "+
+		"WHERE ch.creator_id = creators.id AND ch.created_at >= ? AND ch.created_at <= ? "+
+		"ORDER BY ch.created_at ASC, ch.id ASC LIMIT 1)", r.Start, end)
+}
+
+// latestCreatorSnapshotJoinForCreatorsClause joins the latest history point at or
+// before a date as creator_snapshots
+func latestCreatorSnapshotJoinForCreatorsClause() string {
+	return "LEFT JOIN creator_history AS creator_snapshots ON creator_snapshots.id = (" +
+		"SELECT snapshot.id FROM creator_history AS snapshot FORCE INDEX (idx_creator_history_creator_created_id) " +
+		"WHERE snapshot.creator_id = creators.id AND snapshot.created_at <= ? " +
+		"ORDER BY snapshot.created_at DESC, snapshot.id DESC LIMIT 1)"
+}
+
+// creatorMetricExprs returns the SQL of each creator metric. With an endDate the
+// latest snapshot must be joined as creator_snapshots; growth needs the range joined.
+func creatorMetricExprs(r *creatorRange, endDate *time.Time) map[string]sqlExpr {
+	counts := make(map[string]sqlExpr, len(creatorCountColumns)+1)
+	for name, column := range creatorCountColumns {
+		counts[name] = sqlExpr{SQL: "creators." + column}
+		if endDate != nil {
+			counts[name] = sqlExpr{SQL: "COALESCE(creator_snapshots." + column + ", creators." + column + ")"}
+		}
+	}
+	counts["MediaCount"] = sqlExpr{SQL: "(" + counts["ImageCount"].SQL + " + " + counts["VideoCount"].SQL + ")"}
+
+	exprs := make(map[string]sqlExpr, len(creatorRangeMetrics))
+	for name, column := range creatorCountColumns {
+		exprs[name] = counts[name]
+		if r != nil {
+			exprs[name+"Change"] = changeExpr(counts[name], "creator_range_start."+column)
+			exprs[name+"ChangePercent"] = changePercentExpr(counts[name], "creator_range_start."+column)
+		}
+	}
+	for name, parts := range creatorRatioMetrics {
+		numerator, denominator := counts[parts[0]], counts[parts[1]]
+		exprs[name] = sqlExpr{
+			SQL: "(CASE WHEN " + denominator.SQL + " > 0 THEN " +
+				"CAST(" + numerator.SQL + " AS DECIMAL(30,10)) / " + denominator.SQL + " ELSE 0 END)",
+		}
+	}
+	return exprs
+}
+
+// loadCreatorRangeStarts returns each creator's oldest history point inside the range
+func loadCreatorRangeStarts(db *gorm.DB, creatorIDs []string, r *creatorRange) (map[string]models.CreatorHistory, error) {
+	starts := make(map[string]models.CreatorHistory, len(creatorIDs))
+	if len(creatorIDs) == 0 {
+		return starts, nil
+	}
+
+	var points []models.CreatorHistory
+	if err := r.join(db.Table("creators")).
+		Select("creator_range_start.*").
+		Where("creators.id IN ?", creatorIDs).
+		Where("creator_range_start.id IS NOT NULL").
+		Scan(&points).Error; err != nil {
+		return nil, err
+	}
+
+	for _, point := range points {
+		starts[point.CreatorID] = point
+	}
+	return starts, nil
+}
+
+// buildCreatorChange computes a creator's growth from its metrics at the range end
+// and the oldest point inside the range, matching the SQL of creatorMetricExprs
+func buildCreatorChange(metrics creatorMetrics, start models.CreatorHistory, hasStart bool) creatorChange {
+	if !hasStart {
+		return creatorChange{}
+	}
+
+	var change creatorChange
+	change.FollowersChange, change.FollowersChangePercent = countChange(metrics.Followers, start.Followers)
+	change.MediaLikesChange, change.MediaLikesChangePercent = countChange(metrics.MediaLikes, start.MediaLikes)
+	change.PostLikesChange, change.PostLikesChangePercent = countChange(metrics.PostLikes, start.PostLikes)
+	change.ImageCountChange, change.ImageCountChangePercent = countChange(metrics.ImageCount, start.ImageCount)
+	change.VideoCountChange, change.VideoCountChangePercent = countChange(metrics.VideoCount, start.VideoCount)
+	return change
+}
+
+// countChange returns the change from start to end and its percentage of start, 0 when start is 0
+func countChange(end, start int64) (int64, float64) {
+	change := end - start
+	if start <= 0 {
+		return change, 0
+	}
+	return change, float64(change) * 100 / float64(start)
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"ftoolbox/models"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// CreatorTagHandler serves the creator-tag affinity counted from discovered posts
+type CreatorTagHandler struct {
+	db *gorm.DB
+}
+
+func NewCreatorTagHandler(db *gorm.DB) *CreatorTagHandler {
+	return &CreatorTagHandler{db: db}
+}
+
+type creatorTagQuery struct {
+	page  int
+	limit int
+	days  int
+}
+
+func parseCreatorTagQuery(c *fiber.Ctx) creatorTagQuery {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	days, _ := strconv.Atoi(c.Query("days", "30"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	if days < 1 || days > 365 {
+		days = 30
+	}
+	return creatorTagQuery{page: page, limit: limit, days: days}
+}
+
+func (q creatorTagQuery) cutoff() time.Time {
+	return time.Now().UTC().AddDate(0, 0, -q.days).Truncate(24 * time.Hour)
+}
+
+// GetCreatorTags returns the tags a creator posted with most over the window
+func (h *CreatorTagHandler) GetCreatorTags(c *fiber.Ctx) error {
+	creatorID := c.Params("id")
+	q := parseCreatorTagQuery(c)
+
+	var creator models.Creator
+	if err := h.db.Where("id = ?", creatorID).First(&creator).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Creator not found"})
+		}
+		zap.L().Error("Failed to fetch creator", zap.String("creator_id", creatorID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch creator"})
+	}
+
+	query := func() *gorm.DB {
+		return h.db.Table("creator_tags_daily ctd").
+			Joins("JOIN tags t ON t.id = ctd.tag_id").
+			Where("ctd.creator_id = ?", creator.ID).
+			Where("ctd.bucket_date >= ?", q.cutoff())
+	}
+
+	var total int64
+	if err := query().Distinct("ctd.tag_id").Count(&total).Error; err != nil {
+		zap.L().Error("Failed to count creator tags", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch creator tags"})
+	}
+
+	var rows []struct {
+		ID         string
+		Tag        string
+		ViewCount  int64
+		Rank       *int
+		PostCount  int64
+		LastPostOn time.Time
+	}
+	if err := query().Select("t.id AS id, t.tag AS tag, t.view_count AS view_count, t.rank AS rank, " +
+		"SUM(ctd.post_count) AS post_count, MAX(ctd.bucket_date) AS last_post_on").
+		Group("t.id, t.tag, t.view_count, t.rank").
+		Order("post_count DESC").
+		Order("t.view_count DESC").
+		Limit(q.limit).
+		Offset((q.page - 1) * q.limit).
+		Scan(&rows).Error; err != nil {
+		zap.L().Error("Failed to fetch creator tags", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch creator tags"})
+	}
+
+	tags := make([]fiber.Map, 0, len(rows))
+	for _, row := range rows {
+		tags = append(tags, fiber.Map{
+			"id":         row.ID,
+			"tag":        row.Tag,
+			"viewCount":  row.ViewCount,
+			"rank":       row.Rank,
+			"postCount":  row.PostCount,
+			"lastPostAt": timeToUnix(row.LastPostOn),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"creator":    creator,
+		"days":       q.days,
+		"tags":       tags,
+		"pagination": buildPagination(q.page, q.limit, total),
+	})
+}
+
+// GetTagCreators returns the creators who posted with a tag most over the window
+func (h *CreatorTagHandler) GetTagCreators(c *fiber.Ctx) error {
+	tagID := c.Params("id")
+	q := parseCreatorTagQuery(c)
+
+	var tag models.Tag
+	if err := h.db.Where("id = ?", tagID).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tag not found"})
+		}
+		zap.L().Error("Failed to fetch tag", zap.String("tag_id", tagID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tag"})
+	}
+
+	query := func() *gorm.DB {
+		return h.db.Table("creator_tags_daily ctd").
+			Joins("JOIN creators c ON c.id = ctd.creator_id").
+			Where("ctd.tag_id = ?", tag.ID).
+			Where("ctd.bucket_date >= ?", q.cutoff())
+	}
+
+	var total int64
+	if err := query().Distinct("ctd.creator_id").Count(&total).Error; err != nil {
+		zap.L().Error("Failed to count tag creators", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tag creators"})
+	}
+
+	var rows []struct {
+		ID          string
+		Username    string
+		DisplayName *string
+		Followers   int64
+		Rank        *int
+		PostCount   int64
+		LastPostOn  time.Time
+	}
+	if err := query().Select("c.id AS id, c.username AS username, c.display_name AS display_name, " +
+		"c.followers AS followers, c.rank AS rank, SUM(ctd.post_count) AS post_count, MAX(ctd.bucket_date) AS last_post_on").
+		Group("c.id, c.username, c.display_name, c.followers, c.rank").
+		Order("post_count DESC").
+		Order("c.followers DESC").
+		Limit(q.limit).
+		Offset((q.page - 1) * q.limit).
+		Scan(&rows).Error; err != nil {
+		zap.L().Error("Failed to fetch tag creators", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tag creators"})
+	}
+
+	creators := make([]fiber.Map, 0, len(rows))
+	for _, row := range rows {
+		creators = append(creators, fiber.Map{
+			"id":          row.ID,
+			"username":    row.Username,
+			"displayName": row.DisplayName,
+			"followers":   row.Followers,
+			"rank":        row.Rank,
+			"postCount":   row.PostCount,
+			"lastPostAt":  timeToUnix(row.LastPostOn),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"tag":        tag,
+		"days":       q.days,
+		"creators":   creators,
+		"pagination": buildPagination(q.page, q.limit, total),
+	})
+}
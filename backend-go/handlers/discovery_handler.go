@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"ftoolbox/models"
+	"ftoolbox/workers"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type DiscoveryHandler struct {
+	db *gorm.DB
+}
+
+func NewDiscoveryHandler(db *gorm.DB) *DiscoveryHandler {
+	return &DiscoveryHandler{db: db}
+}
+
+// GetFrontier lists discovery candidates by score; seeds=true limits it to seeds
+func (h *DiscoveryHandler) GetFrontier(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	seedsOnly := c.Query("seeds") == "true"
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	query := h.db.Model(&models.DiscoveryFrontier{})
+	if seedsOnly {
+		query = query.Where("is_seed = ?", true)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		zap.L().Error("Failed to count discovery frontier", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch discovery frontier"})
+	}
+
+	var entries []models.DiscoveryFrontier
+	if err := query.Order("score DESC").Order("tag ASC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&entries).Error; err != nil {
+		zap.L().Error("Failed to fetch discovery frontier", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch discovery frontier"})
+	}
+
+	return c.JSON(fiber.Map{
+		"frontier":   entries,
+		"pagination": buildPagination(page, limit, total),
+	})
+}
+
+func (h *DiscoveryHandler) AddSeeds(c *fiber.Ctx) error {
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	tags := make([]string, 0, len(req.Tags))
+	for _, tag := range req.Tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "tags is required"})
+	}
+
+	if err := workers.AddDiscoverySeeds(h.db, tags); err != nil {
+		zap.L().Error("Failed to add discovery seeds", zap.Strings("tags", tags), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to add seeds"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Seeds added", "tags": tags})
+}
+
+func (h *DiscoveryHandler) RemoveSeed(c *fiber.Ctx) error {
+	tag := c.Params("tag")
+	removed, err := workers.RemoveDiscoverySeed(h.db, tag)
+	if err != nil {
+		zap.L().Error("Failed to remove discovery seed", zap.String("tag", tag), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to remove seed"})
+	}
+	if !removed {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Seed not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Seed removed", "tag": tag})
+}
@@ -1,6 +1,17 @@
 package handlers
 
-import "time"
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultHistoryPoints and maxHistoryPoints bound the maxPoints of detail history
+const (
+	defaultHistoryPoints = 500
+	maxHistoryPoints     = 5000
+)
 
 func parseHistoryDate(value string) *time.Time {
 	if value == "" {
@@ -19,3 +30,45 @@ func parseHistoryDate(value string) *time.Time {
 
 	return nil
 }
+
+// parseHistoryPoints reads maxPoints: 0 asks for the full history, anything else is
+// clamped to 2..maxHistoryPoints
+func parseHistoryPoints(c *fiber.Ctx) int {
+	points, err := strconv.Atoi(c.Query("maxPoints", strconv.Itoa(defaultHistoryPoints)))
+	if err != nil || points < 0 {
+		return defaultHistoryPoints
+	}
+	if points == 0 {
+		return 0
+	}
+	return min(max(points, 2), maxHistoryPoints)
+}
+
+// downsampleHistory keeps at most maxPoints of a newest-first history: the newest
+// point of each equal slice of its time span, plus the oldest point. Refresh
+// intervals vary, so slicing by time keeps busy periods from crowding out the rest.
+// maxPoints <= 0 keeps every point.
+func downsampleHistory[T any](points []T, maxPoints int, at func(T) time.Time) []T {
+	if maxPoints <= 0 || len(points) <= maxPoints {
+		return points
+	}
+
+	newest, oldest := at(points[0]), at(points[len(points)-1])
+	span := newest.Sub(oldest)
+	buckets := maxPoints - 1
+	sampled := make([]T, 0, maxPoints)
+	lastBucket := -1
+	for _, point := range points[:len(points)-1] {
+		bucket := 0
+		if span > 0 {
+			bucket = min(int(float64(newest.Sub(at(point)))/float64(span)*float64(buckets)), buckets-1)
+		}
+		if bucket == lastBucket {
+			continue
+		}
+		lastBucket = bucket
+		sampled = append(sampled, point)
+	}
+
+	return append(sampled, points[len(points)-1])
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"errors"
+	"ftoolbox/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type PostHandler struct {
+	db *gorm.DB
+}
+
+func NewPostHandler(db *gorm.DB) *PostHandler {
+	return &PostHandler{db: db}
+}
+
+type PostData struct {
+	ID                  string   `json:"id"`
+	AccountID           string   `json:"accountId"`
+	Content             string   `json:"content"`
+	LikeCount           int64    `json:"likeCount"`
+	ReplyCount          int64    `json:"replyCount"`
+	MediaLikeCount      int64    `json:"mediaLikeCount"`
+	TotalTipAmount      int64    `json:"totalTipAmount"`
+	TipAmount           int64    `json:"tipAmount"`
+	AttachmentTipAmount int64    `json:"attachmentTipAmount"`
+	AttachmentCount     int      `json:"attachmentCount"`
+	FanslyCreatedAt     int64    `json:"fanslyCreatedAt"`
+	ExpiresAt           *int64   `json:"expiresAt"`
+	LastSeenAt          int64    `json:"lastSeenAt"`
+	Tags                []string `json:"tags"`
+}
+
+// topPostsQuery holds the paging and ordering shared by the top posts endpoints
+type topPostsQuery struct {
+	page    int
+	limit   int
+	orderBy string
+	since   *time.Time
+}
+
+func parseTopPostsQuery(c *fiber.Ctx) topPostsQuery {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	days, _ := strconv.Atoi(c.Query("days", "0"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	q := topPostsQuery{page: page, limit: limit, orderBy: postOrderColumn(c.Query("sortBy", "likes"))}
+	if days > 0 {
+		since := time.Now().AddDate(0, 0, -days)
+		q.since = &since
+	}
+	return q
+}
+
+func postOrderColumn(sortBy string) string {
+	switch strings.ToLower(sortBy) {
+	case "replies":
+		return "p.reply_count"
+	case "medialikes":
+		return "p.media_like_count"
+	case "tips":
+		return "p.total_tip_amount"
+	case "recent":
+		return "p.fansly_created_at"
+	default:
+		return "p.like_count"
+	}
+}
+
+// GetTagPosts returns the top posts carrying a tag
+func (h *PostHandler) GetTagPosts(c *fiber.Ctx) error {
+	tagID := c.Params("id")
+
+	var tag models.Tag
+	if err := h.db.Where("id = ?", tagID).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tag not found"})
+		}
+		zap.L().Error("Failed to fetch tag", zap.String("tag_id", tagID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tag"})
+	}
+
+	query := h.db.Table("posts p").
+		Joins("JOIN post_tags pt ON pt.post_id = p.id").
+		Where("pt.tag_id = ?", tag.ID)
+	return h.respondTopPosts(c, query, fiber.Map{"tag": tag})
+}
+
+// GetCreatorPosts returns the top posts of a creator
+func (h *PostHandler) GetCreatorPosts(c *fiber.Ctx) error {
+	creatorID := c.Params("id")
+
+	var creator models.Creator
+	if err := h.db.Where("id = ?", creatorID).First(&creator).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Creator not found"})
+		}
+		zap.L().Error("Failed to fetch creator", zap.String("creator_id", creatorID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch creator"})
+	}
+
+	query := h.db.Table("posts p").Where("p.account_id = ?", creator.ID)
+	return h.respondTopPosts(c, query, fiber.Map{"creator": creator})
+}
+
+func (h *PostHandler) respondTopPosts(c *fiber.Ctx, query *gorm.DB, response fiber.Map) error {
+	q := parseTopPostsQuery(c)
+	if q.since != nil {
+		query = query.Where("p.fansly_created_at >= ?", *q.since)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		zap.L().Error("Failed to count posts", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch posts"})
+	}
+
+	var posts []models.Post
+	if err := query.Select("p.*").
+		Order(q.orderBy + " DESC").
+		Order("p.id DESC").
+		Limit(q.limit).
+		Offset((q.page - 1) * q.limit).
+		Find(&posts).Error; err != nil {
+		zap.L().Error("Failed to fetch posts", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch posts"})
+	}
+
+	tagsByPost, err := h.loadPostTagNames(posts)
+	if err != nil {
+		zap.L().Error("Failed to fetch post tags", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch post tags"})
+	}
+
+	response["posts"] = buildPostData(posts, tagsByPost)
+	response["pagination"] = buildPagination(q.page, q.limit, total)
+	return c.JSON(response)
+}
+
+func (h *PostHandler) loadPostTagNames(posts []models.Post) (map[string][]string, error) {
+	tagsByPost := make(map[string][]string, len(posts))
+	if len(posts) == 0 {
+		return tagsByPost, nil
+	}
+
+	postIDs := make([]string, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+
+	var rows []struct {
+		PostID string
+		Tag    string
+	}
+	if err := h.db.Table("post_tags pt").
+		Select("pt.post_id, t.tag").
+		Joins("JOIN tags t ON t.id = pt.tag_id").
+		Where("pt.post_id IN ?", postIDs).
+		Order("t.tag").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		tagsByPost[row.PostID] = append(tagsByPost[row.PostID], row.Tag)
+	}
+	return tagsByPost, nil
+}
+
+func buildPostData(posts []models.Post, tagsByPost map[string][]string) []PostData {
+	result := make([]PostData, len(posts))
+	for i, post := range posts {
+		tags := tagsByPost[post.ID]
+		if tags == nil {
+			tags = []string{}
+		}
+		result[i] = PostData{
+			ID:                  post.ID,
+			AccountID:           post.AccountID,
+			Content:             post.Content,
+			LikeCount:           post.LikeCount,
+			ReplyCount:          post.ReplyCount,
+			MediaLikeCount:      post.MediaLikeCount,
+			TotalTipAmount:      post.TotalTipAmount,
+			TipAmount:           post.TipAmount,
+			AttachmentTipAmount: post.AttachmentTipAmount,
+			AttachmentCount:     post.AttachmentCount,
+			FanslyCreatedAt:     timeToUnix(post.FanslyCreatedAt),
+			ExpiresAt:           timeToUnixPtr(post.ExpiresAt),
+			LastSeenAt:          timeToUnix(post.LastSeenAt),
+			Tags:                tags,
+		}
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sqlExpr is a SQL expression with its placeholder arguments
+type sqlExpr struct {
+	SQL  string
+	Args []any
+}
+
+// rangeBound is an optional min/max pair for one metric
+type rangeBound struct {
+	Min *float64
+	Max *float64
+}
+
+// parseRangeBounds reads the min<Name> and max<Name> query parameters of each metric
+// name, returning only the metrics that have a bound
+func parseRangeBounds(c *fiber.Ctx, names []string) (map[string]rangeBound, error) {
+	bounds := make(map[string]rangeBound)
+	for _, name := range names {
+		var bound rangeBound
+		for _, side := range []struct {
+			param  string
+			target **float64
+		}{
+			{"min" + name, &bound.Min},
+			{"max" + name, &bound.Max},
+		} {
+			raw := c.Query(side.param)
+			if raw == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+				return nil, fmt.Errorf("invalid %s", side.param)
+			}
+			*side.target = &value
+		}
+		if bound.Min != nil || bound.Max != nil {
+			bounds[name] = bound
+		}
+	}
+	return bounds, nil
+}
+
+// applyRangeBounds bounds each metric's expression by its min and max
+func applyRangeBounds(query *gorm.DB, bounds map[string]rangeBound, exprs map[string]sqlExpr) *gorm.DB {
+	for _, name := range slices.Sorted(maps.Keys(bounds)) {
+		bound := bounds[name]
+		expr, ok := exprs[name]
+		if !ok {
+			continue
+		}
+		if bound.Min != nil {
+			query = query.Where("("+expr.SQL+") >= ?", append(append([]any{}, expr.Args...), *bound.Min)...)
+		}
+		if bound.Max != nil {
+			query = query.Where("("+expr.SQL+") <= ?", append(append([]any{}, expr.Args...), *bound.Max)...)
+		}
+	}
+	return query
+}
+
+// orderByExpr orders by an expression that has placeholder arguments, then by the
+// tie-breakers. GORM drops an expression order merged with a later Order call, so
+// tie-breakers must be passed here.
+func orderByExpr(expr sqlExpr, order string, tieBreakers ...string) clause.OrderBy {
+	sql := expr.SQL + " " + order
+	for _, tieBreaker := range tieBreakers {
+		sql += ", " + tieBreaker
+	}
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:                sql,
+		Vars:               expr.Args,
+		WithoutParentheses: true,
+	}}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// rankMovementDays are the periods rank movement is reported over
+var rankMovementDays = []int{1, 7, 30}
+
+// RankPoint is the last rank of a day
+type RankPoint struct {
+	Date string `json:"date"`
+	Rank int    `json:"rank"`
+}
+
+// loadRankHistory returns the daily ranks of one record from a *_ranks_daily table,
+// oldest first, optionally limited to a date range
+func loadRankHistory(db *gorm.DB, table, idColumn, id string, startDate, endDate *time.Time) ([]RankPoint, error) {
+	query := db.Table(table).
+		Select("bucket_date, rank").
+		Where(idColumn+" = ?", id).
+		Order("bucket_date ASC")
+	if startDate != nil {
+		query = query.Where("bucket_date >= ?", startDate.UTC().Truncate(24*time.Hour))
+	}
+	if endDate != nil {
+		query = query.Where("bucket_date <= ?", *endDate)
+	}
+
+	var rows []struct {
+		BucketDate time.Time
+		Rank       int
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	points := make([]RankPoint, len(rows))
+	for i, row := range rows {
+		points[i] = RankPoint{Date: row.BucketDate.Format("2006-01-02"), Rank: row.Rank}
+	}
+	return points, nil
+}
+
+// rankMovement is how many places the rank climbed since the end of the day N days
+// ago, keyed "<N>d"; nil when there is no rank to compare
+func rankMovement(current *int, history []RankPoint, now time.Time) map[string]*int {
+	byDate := make(map[string]int, len(history))
+	for _, point := range history {
+		byDate[point.Date] = point.Rank
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	movement := make(map[string]*int, len(rankMovementDays))
+	for _, days := range rankMovementDays {
+		key := strconv.Itoa(days) + "d"
+		movement[key] = nil
+		past, ok := byDate[today.AddDate(0, 0, -days).Format("2006-01-02")]
+		if current != nil && ok {
+			movement[key] = ptr(past - *current)
+		}
+	}
+	return movement
+}
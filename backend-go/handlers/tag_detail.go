@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"ftoolbox/models"
+	"ftoolbox/tagfilter"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Related tags in a tag's detail, scored like GetRelatedTags with its defaults
+const (
+	tagDetailRelatedLimit      = 10
+	tagDetailRelatedWindowDays = 14
+	tagDetailRelatedMinViews   = 5000
+)
+
+// TagStatusEventData is a ban or unban of a tag
+type TagStatusEventData struct {
+	Event     string `json:"event"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// RelatedTagData is a tag often posted together with another
+type RelatedTagData struct {
+	ID    string  `json:"id"`
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+}
+
+// GetTag returns the full profile of a tag by ID
+func (h *TagHandler) GetTag(c *fiber.Ctx) error {
+	return h.respondTagDetail(c, h.db.Where("id = ?", c.Params("id")))
+}
+
+// GetTagByName returns the full profile of a tag by name, with or without the leading #
+func (h *TagHandler) GetTagByName(c *fiber.Ctx) error {
+	name, err := url.PathUnescape(c.Params("tag"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid tag"})
+	}
+
+	return h.respondTagDetail(c, h.db.Where("tag = ?", strings.TrimLeft(strings.TrimSpace(name), "#")))
+}
+
+// respondTagDetail answers with the tag the query finds: its current metrics, rank
+// and rank movement, history in historyStartDate..historyEndDate downsampled to
+// maxPoints, ban and unban events, top related tags and where it was first seen.
+// Tags the filter rules exclude are not found.
+func (h *TagHandler) respondTagDetail(c *fiber.Ctx, query *gorm.DB) error {
+	startDate := parseHistoryDate(c.Query("historyStartDate"))
+	endDate := parseHistoryDate(c.Query("historyEndDate"))
+	maxPoints := parseHistoryPoints(c)
+
+	var tag models.Tag
+	if err := query.Scopes(tagfilter.Current(h.db).Allowed("tag", "view_count")).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tag not found"})
+		}
+		zap.L().Error("Failed to fetch tag", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tag"})
+	}
+
+	historyByTag, err := h.loadTagHistoryByTag([]string{tag.ID}, startDate, endDate)
+	if err != nil {
+		zap.L().Error("Failed to fetch tag history", zap.String("tag", tag.Tag), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tag history"})
+	}
+	history := historyByTag[tag.ID]
+	sampled := downsampleHistory(history, maxPoints, func(point models.TagHistory) time.Time {
+		return point.CreatedAt
+	})
+
+	now := time.Now()
+	rankHistory, err := loadRankHistory(h.db, "tag_ranks_daily", "tag_id", tag.ID, startDate, endDate)
+	if err != nil {
+		zap.L().Error("Failed to fetch tag rank history", zap.String("tag", tag.Tag), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tag rank history"})
+	}
+	recentRanks, err := loadRankHistory(h.db, "tag_ranks_daily", "tag_id", tag.ID,
+		ptr(now.AddDate(0, 0, -rankMovementDays[len(rankMovementDays)-1]-1)), nil)
+	if err != nil {
+		zap.L().Error("Failed to fetch tag rank history", zap.String("tag", tag.Tag), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tag rank history"})
+	}
+
+	var events []models.TagStatusEvent
+	if err := h.db.Where("tag_id = ?", tag.ID).Order("created_at ASC, id ASC").Find(&events).Error; err != nil {
+		zap.L().Error("Failed to fetch tag status events", zap.String("tag", tag.Tag), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tag status events"})
+	}
+
+	cutoff := now.UTC().AddDate(0, 0, -tagDetailRelatedWindowDays).Truncate(24 * time.Hour)
+	related, err := h.loadRelatedTags([]string{tag.ID}, cutoff, tagDetailRelatedMinViews, 1)
+	if err != nil {
+		zap.L().Error("Failed to fetch related tags", zap.String("tag", tag.Tag), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch related tags"})
+	}
+
+	var firstHistory models.TagHistory
+	firstHistoryErr := h.db.Where("tag_id = ?", tag.ID).Order("created_at ASC, id ASC").Take(&firstHistory).Error
+	if firstHistoryErr != nil && !errors.Is(firstHistoryErr, gorm.ErrRecordNotFound) {
+		zap.L().Error("Failed to fetch tag history", zap.String("tag", tag.Tag), zap.Error(firstHistoryErr))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tag history"})
+	}
+	var firstHistoryAt *int64
+	if firstHistoryErr == nil {
+		firstHistoryAt = ptr(firstHistory.CreatedAt.Unix())
+	}
+
+	return c.JSON(fiber.Map{
+		"tag": buildTagWithHistory(tag, map[string]models.TagHistory{}, sampled, nil, nil),
+		"rank": fiber.Map{
+			"current":  tag.Rank,
+			"movement": rankMovement(tag.Rank, recentRanks, now),
+			"history":  rankHistory,
+		},
+		"historyPoints": fiber.Map{
+			"total":    len(history),
+			"returned": len(sampled),
+		},
+		"events":      buildTagStatusEvents(events),
+		"relatedTags": buildRelatedTagData(related, tagDetailRelatedLimit),
+		"provenance": fiber.Map{
+			"source":          tag.Source,
+			"firstSeenAt":     tag.CreatedAt.Unix(),
+			"firstHistoryAt":  firstHistoryAt,
+			"fanslyCreatedAt": tag.FanslyCreatedAt.Unix(),
+		},
+	})
+}
+
+func buildTagStatusEvents(events []models.TagStatusEvent) []TagStatusEventData {
+	data := make([]TagStatusEventData, len(events))
+	for i, event := range events {
+		data[i] = TagStatusEventData{Event: event.Event, CreatedAt: event.CreatedAt.Unix()}
+	}
+	return data
+}
+
+func buildRelatedTagData(related []relatedTagScore, limit int) []RelatedTagData {
+	related = related[:min(limit, len(related))]
+	data := make([]RelatedTagData, len(related))
+	for i, r := range related {
+		data[i] = RelatedTagData{ID: r.ID, Tag: r.Tag, Score: r.FinalScore}
+	}
+	return data
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"ftoolbox/models"
+	"ftoolbox/tagfilter"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type TagFilterHandler struct {
+	db *gorm.DB
+}
+
+func NewTagFilterHandler(db *gorm.DB) *TagFilterHandler {
+	return &TagFilterHandler{db: db}
+}
+
+type tagFilterRuleRequest struct {
+	Type      *string `json:"type"`
+	Value     *string `json:"value"`
+	MinViews  *int64  `json:"minViews"`
+	IsEnabled *bool   `json:"isEnabled"`
+	Note      *string `json:"note"`
+}
+
+// apply copies the fields set in the request onto rule
+func (r tagFilterRuleRequest) apply(rule *models.TagFilterRule) {
+	if r.Type != nil {
+		rule.Type = strings.TrimSpace(*r.Type)
+	}
+	if r.Value != nil {
+		rule.Value = strings.TrimSpace(*r.Value)
+	}
+	if r.MinViews != nil {
+		rule.MinViews = *r.MinViews
+	}
+	if r.IsEnabled != nil {
+		rule.IsEnabled = *r.IsEnabled
+	}
+	if r.Note != nil {
+		rule.Note = strings.TrimSpace(*r.Note)
+	}
+}
+
+func (h *TagFilterHandler) ListRules(c *fiber.Ctx) error {
+	var rules []models.TagFilterRule
+	if err := h.db.Order("id").Find(&rules).Error; err != nil {
+		zap.L().Error("Failed to fetch tag filter rules", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tag filter rules"})
+	}
+
+	return c.JSON(fiber.Map{"rules": rules})
+}
+
+func (h *TagFilterHandler) CreateRule(c *fiber.Ctx) error {
+	var req tagFilterRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	rule := models.TagFilterRule{IsEnabled: true}
+	req.apply(&rule)
+	if err := tagfilter.ValidateInDB(h.db, rule); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.db.Create(&rule).Error; err != nil {
+		zap.L().Error("Failed to create tag filter rule", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create tag filter rule"})
+	}
+	tagfilter.Invalidate()
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"rule": rule})
+}
+
+func (h *TagFilterHandler) UpdateRule(c *fiber.Ctx) error {
+	rule, err := h.findRule(c)
+	if rule == nil {
+		return err
+	}
+
+	var req tagFilterRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	req.apply(rule)
+	if err := tagfilter.ValidateInDB(h.db, *rule); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rule.UpdatedAt = time.Now()
+	if err := h.db.Save(rule).Error; err != nil {
+		zap.L().Error("Failed to update tag filter rule", zap.Uint("id", rule.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update tag filter rule"})
+	}
+	tagfilter.Invalidate()
+
+	return c.JSON(fiber.Map{"rule": rule})
+}
+
+func (h *TagFilterHandler) DeleteRule(c *fiber.Ctx) error {
+	rule, err := h.findRule(c)
+	if rule == nil {
+		return err
+	}
+
+	if err := h.db.Delete(rule).Error; err != nil {
+		zap.L().Error("Failed to delete tag filter rule", zap.Uint("id", rule.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete tag filter rule"})
+	}
+	tagfilter.Invalidate()
+
+	return c.JSON(fiber.Map{"message": "Rule deleted", "id": rule.ID})
+}
+
+// findRule loads the rule named by the :id parameter. If it can't, it writes the
+// error response and returns a nil rule.
+func (h *TagFilterHandler) findRule(c *fiber.Ctx) (*models.TagFilterRule, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return nil, c.Status(400).JSON(fiber.Map{"error": "Invalid rule id"})
+	}
+
+	var rule models.TagFilterRule
+	if err := h.db.First(&rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Rule not found"})
+		}
+		zap.L().Error("Failed to fetch tag filter rule", zap.Uint64("id", id), zap.Error(err))
+		return nil, c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tag filter rule"})
+	}
+	return &rule, nil
+}
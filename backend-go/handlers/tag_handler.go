@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"ftoolbox/fansly"
 	"ftoolbox/models"
+	"ftoolbox/ratelimit"
+	"ftoolbox/services"
+	"ftoolbox/tagfilter"
 	"ftoolbox/utils"
 	"math"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -18,13 +21,15 @@ import (
 
 type TagHandler struct {
 	db           *gorm.DB
-	fanslyClient *fansly.Client
+	fanslyClient fansly.API
+	tags         *services.TagService
 }
 
-func NewTagHandler(db *gorm.DB, fanslyClient *fansly.Client) *TagHandler {
+func NewTagHandler(db *gorm.DB, fanslyClient fansly.API) *TagHandler {
 	return &TagHandler{
 		db:           db,
 		fanslyClient: fanslyClient,
+		tags:         services.NewTagService(db),
 	}
 }
 
@@ -51,6 +56,9 @@ type TagWithHistory struct {
 	Heat                 float64        `json:"heat"`
 	FanslyCreatedAt      *int64         `json:"fanslyCreatedAt"`
 	LastCheckedAt        *int64         `json:"lastCheckedAt"`
+	NextRefreshAt        *int64         `json:"nextRefreshAt"`
+	IsWatched            bool           `json:"isWatched"`
+	Source               string         `json:"source"`
 	LastUsedForDiscovery *int64         `json:"lastUsedForDiscovery"`
 	IsDeleted            bool           `json:"isDeleted"`
 	DeletedDetectedAt    *int64         `json:"deletedDetectedAt"`
@@ -58,6 +66,7 @@ type TagWithHistory struct {
 	UpdatedAt            int64          `json:"updatedAt"`
 	History              []HistoryPoint `json:"history,omitempty"`
 	TotalChange          int64          `json:"totalChange"`
+	RangeChange          *tagChange     `json:"rangeChange,omitempty"`
 }
 
 type tagMetrics struct {
@@ -86,30 +95,23 @@ type tagSortOptions struct {
 	By      string
 	Order   string
 	EndDate *time.Time
+	Exprs   map[string]sqlExpr // range metrics, for growth sorts
 }
 
 func extractHashtags(q string) []string {
 	if strings.TrimSpace(q) == "" {
 		return nil
 	}
-	r := regexp.MustCompile(`#([\p{L}\p{N}_-]+)`) // letters, numbers, underscore, dash
-	matches := r.FindAllStringSubmatch(q, -1)
-	if len(matches) == 0 {
-		if strings.HasPrefix(strings.TrimSpace(q), "#") {
-			v := strings.TrimLeft(strings.TrimSpace(q), "#")
-			if v != "" {
-				return []string{v}
-			}
-		}
-		return nil
+	if hashtags := utils.ExtractHashtags(q); len(hashtags) > 0 {
+		return hashtags
 	}
-	out := make([]string, 0, len(matches))
-	for _, m := range matches {
-		if len(m) > 1 && m[1] != "" {
-			out = append(out, m[1])
+	if strings.HasPrefix(strings.TrimSpace(q), "#") {
+		v := strings.TrimLeft(strings.TrimSpace(q), "#")
+		if v != "" {
+			return []string{v}
 		}
 	}
-	return out
+	return nil
 }
 
 func (h *TagHandler) GetTags(c *fiber.Ctx) error {
@@ -135,12 +137,29 @@ func (h *TagHandler) GetTags(c *fiber.Ctx) error {
 	offset := (page - 1) * limit
 	startDate := parseHistoryDate(historyStartDate)
 	endDate := parseHistoryDate(historyEndDate)
-	targetTags, requestedTagsFilteredOut := parseRequestedTags(tagsParam)
+	rules := tagfilter.Current(h.db)
+	targetTags, requestedTagsFilteredOut := parseRequestedTags(rules, tagsParam)
 	search, targetTags = resolveTagSearch(search, targetTags)
 
+	bounds, err := parseRangeBounds(c, tagRangeMetrics)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	changeRange := resolveTagRange(startDate, endDate, sortBy, bounds)
+	metricExprs := tagMetricExprs(changeRange, endDate)
+
 	var tags []models.Tag
-	query := applyTagFilters(h.db.Model(&models.Tag{}), search, targetTags, requestedTagsFilteredOut).
-		Where("rank IS NOT NULL")
+	query := applyTagFilters(h.db.Model(&models.Tag{}), rules, search, targetTags, requestedTagsFilteredOut).
+		Where("tags.rank IS NOT NULL")
+
+	// Metrics at a past range end come from the latest snapshot before it
+	if endDate != nil && (sortBy == "ratio" || changeRange != nil || len(bounds) > 0) {
+		query = query.Joins(latestTagSnapshotJoinForTagsClause(), *endDate)
+	}
+	if changeRange != nil {
+		query = changeRange.join(query)
+	}
+	query = applyRangeBounds(query, bounds, metricExprs)
 
 	var total int64
 	query.Count(&total)
@@ -150,6 +169,7 @@ func (h *TagHandler) GetTags(c *fiber.Ctx) error {
 		By:      sortBy,
 		Order:   sortOrder,
 		EndDate: endDate,
+		Exprs:   metricExprs,
 	})
 
 	if len(targetTags) == 0 {
@@ -168,6 +188,15 @@ func (h *TagHandler) GetTags(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tag snapshots"})
 	}
 
+	var rangeStarts map[string]models.TagHistory
+	if changeRange != nil {
+		rangeStarts, err = loadTagRangeStarts(h.db, tagIDs, changeRange)
+		if err != nil {
+			zap.L().Error("Failed to fetch tag range starts", zap.Error(err))
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tag snapshots"})
+		}
+	}
+
 	if needsHistory {
 		historyByTag, err := h.loadTagHistoryByTag(tagIDs, startDate, endDate)
 		if err != nil {
@@ -176,13 +205,13 @@ func (h *TagHandler) GetTags(c *fiber.Ctx) error {
 		}
 
 		return c.JSON(fiber.Map{
-			"tags":       buildTagsWithHistory(tags, tagSnapshots, historyByTag, endDate),
+			"tags":       buildTagsWithHistory(tags, tagSnapshots, historyByTag, rangeStarts, endDate),
 			"pagination": buildPagination(page, limit, total),
 		})
 	}
 
 	return c.JSON(fiber.Map{
-		"tags":       buildTagData(tags, tagSnapshots, endDate),
+		"tags":       buildTagData(tags, tagSnapshots, rangeStarts, endDate),
 		"pagination": buildPagination(page, limit, total),
 	})
 }
@@ -251,11 +280,6 @@ func (h *TagHandler) GetBannedTags(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch banned tags"})
 	}
 
-	// Force heat to 0 in responses
-	for i := range tags {
-		tags[i].Heat = 0
-	}
-
 	// Calculate statistics for banned tags
 	var stats struct {
 		TotalBanned   int64 `json:"totalBanned"`
@@ -285,7 +309,7 @@ func (h *TagHandler) GetBannedTags(c *fiber.Ctx) error {
 func buildBannedTagBaseQuery(db *gorm.DB) *gorm.DB {
 	return db.Model(&models.Tag{}).
 		Where("is_deleted = ?", true).
-		Where("tag NOT LIKE ?", "%+%")
+		Scopes(tagfilter.Current(db).Allowed("tag", "view_count"))
 }
 
 func applyBannedTagSearch(query *gorm.DB, search string) *gorm.DB {
@@ -347,77 +371,61 @@ func (h *TagHandler) RequestTag(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
-	if validationError := validateRequestedTag(req.Tag); validationError != "" {
+	rules := tagfilter.Current(h.db)
+	if validationError := validateRequestedTag(rules, req.Tag); validationError != "" {
 		return c.Status(400).JSON(fiber.Map{"error": validationError})
 	}
 
 	// Check if tag already exists
 	var existingTag models.Tag
 	if err := h.db.Where("tag = ?", req.Tag).First(&existingTag).Error; err == nil {
-		// Return existing tag like old backend
-		return c.JSON(fiber.Map{
-			"message": "Tag is already being tracked",
-			"tag":     existingTag,
-		})
+		return h.respondTagAlreadyTracked(c, &existingTag)
 	}
 
-	// Immediately try to fetch tag data from Fansly
-	fanslyTag, err := h.fanslyClient.GetTagWithContext(c.Context(), req.Tag)
+	// Immediately try to fetch tag data from Fansly, ahead of background work
+	ctx := ratelimit.WithPriority(c.Context(), ratelimit.PriorityInteractive)
+	fanslyTag, err := h.fanslyClient.GetTagWithContext(ctx, req.Tag)
 
+	if errors.Is(err, fansly.ErrUnavailable) {
+		return c.Status(503).JSON(fiber.Map{"error": "Fansly is currently unavailable, please try again later"})
+	}
 	if err != nil || fanslyTag == nil {
 		return c.Status(404).JSON(fiber.Map{"error": "Tag not found on Fansly"})
 	}
-
-	// Insert tag into database
-	newTag := models.Tag{
-		ID:              fanslyTag.MediaOfferSuggestionTag.ID,
-		Tag:             fanslyTag.MediaOfferSuggestionTag.Tag,
-		ViewCount:       fanslyTag.MediaOfferSuggestionTag.ViewCount,
-		PostCount:       fanslyTag.MediaOfferSuggestionTag.PostCount,
-		FanslyCreatedAt: time.Unix(fanslyTag.MediaOfferSuggestionTag.CreatedAt/1000, 0),
-		LastCheckedAt:   &[]time.Time{time.Now()}[0],
+	if rules.Excludes(fanslyTag.MediaOfferSuggestionTag.Tag, fanslyTag.MediaOfferSuggestionTag.ViewCount) {
+		return c.Status(400).JSON(fiber.Map{"error": "Tag is excluded by the tag filter rules"})
 	}
 
-	if err := h.db.Create(&newTag).Error; err != nil {
+	// Store the tag with its initial history point and a provisional rank
+	newTag, created, err := h.tags.Create(fanslyTag.MediaOfferSuggestionTag, services.TagCreateOptions{
+		Source:  models.TagSourceRequest,
+		Watched: true,
+	})
+	if err != nil {
 		zap.L().Error("Failed to create tag", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create tag"})
 	}
-
-	// Insert initial history record
-	history := models.TagHistory{
-		TagID:           newTag.ID,
-		ViewCount:       newTag.ViewCount,
-		Change:          0, // Initial entry has no change
-		PostCount:       newTag.PostCount,
-		PostCountChange: 0, // Initial entry has no change
-	}
-
-	if err := h.db.Create(&history).Error; err != nil {
-		zap.L().Error("Failed to create tag history", zap.Error(err))
+	if !created {
+		return h.respondTagAlreadyTracked(c, newTag)
 	}
 
-	// Calculate ranks after adding the new tag
-	if err := utils.CalculateTagRanks(h.db); err != nil {
-		zap.L().Error("Failed to calculate ranks", zap.Error(err))
-	}
+	return c.JSON(fiber.Map{
+		"message": "Tag added successfully",
+		"tag":     newTag,
+	})
+}
 
-	// Retrieve the tag again to get the calculated rank and heat
-	var tagWithRank models.Tag
-	if err := h.db.Where("id = ?", newTag.ID).First(&tagWithRank).Error; err != nil {
-		zap.L().Error("Failed to retrieve tag with rank", zap.Error(err))
-		// Return the tag without rank if retrieval fails
-		return c.JSON(fiber.Map{
-			"message": "Tag added successfully",
-			"tag":     newTag,
-		})
+// respondTagAlreadyTracked answers a request for a stored tag. A user asking for a
+// tag marks it as watched, which refreshes it more often.
+func (h *TagHandler) respondTagAlreadyTracked(c *fiber.Ctx, tag *models.Tag) error {
+	if err := h.tags.Watch(tag); err != nil {
+		zap.L().Error("Failed to mark tag as watched", zap.String("tag", tag.Tag), zap.Error(err))
 	}
 
-	// Ensure heat is 0 in response
-	tagWithRank.Heat = 0
-
+	// Return existing tag like old backend
 	return c.JSON(fiber.Map{
-		"message": "Tag added successfully",
-		"tag":     tagWithRank,
+		"message": "Tag is already being tracked",
+		"tag":     tag,
 	})
 }
 
@@ -470,7 +478,7 @@ func (h *TagHandler) GetRelatedTags(c *fiber.Ctx) error {
 	var srcTags []models.Tag
 	if err := h.db.Model(&models.Tag{}).
 		Where("tag IN ?", inputs).
-		Where("tag NOT LIKE ?", "%+%").
+		Scopes(tagfilter.Current(h.db).Allowed("tag", "")).
 		Find(&srcTags).Error; err != nil {
 		zap.L().Error("Failed to resolve tags", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to resolve tags"})
@@ -502,6 +510,43 @@ func (h *TagHandler) GetRelatedTags(c *fiber.Ctx) error {
 		minCoverage = len(srcIDs)
 	}
 
+	scoredRows, err := h.loadRelatedTags(srcIDs, cutoff, minViewCount, minCoverage)
+	if err != nil {
+		zap.L().Error("Failed to query related tags (smart)", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch related tags"})
+	}
+
+	if limit > len(scoredRows) {
+		limit = len(scoredRows)
+	}
+	out := scoredRows[:limit]
+
+	resp := make([]map[string]any, 0, len(out))
+	for _, r := range out {
+		resp = append(resp, map[string]any{
+			"id":         r.ID,
+			"tag":        r.Tag,
+			"normScore":  r.NormAvg,
+			"coverage":   r.Coverage,
+			"finalScore": r.FinalScore,
+			"score":      r.FinalScore, // Back-compat: keep 'score'
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"tags":         resp,
+		"source":       "computed",
+		"mode":         mode,
+		"windowDays":   windowDays,
+		"minViewCount": minViewCount,
+		"minCoverage":  minCoverage,
+		"usedTagIds":   srcIDs,
+	})
+}
+
+// loadRelatedTags scores the tags posted together with the source tags since cutoff,
+// best first. Related tags must be co-used with at least minCoverage source tags.
+func (h *TagHandler) loadRelatedTags(srcIDs []string, cutoff time.Time, minViewCount, minCoverage int) ([]relatedTagScore, error) {
 	// Query base aggregates; compute popularity shaping and final score in Go for portability
 	var srows []relatedTagAggregate
 
@@ -521,15 +566,14 @@ func (h *TagHandler) GetRelatedTags(c *fiber.Ctx) error {
 		Where("tr.tag_id IN ?", srcIDs).
 		Where("tr.bucket_date >= ?", cutoff).
 		Where("t.is_deleted = ?", false).
-		Where("t.tag NOT LIKE ?", "%+%").
+		Scopes(tagfilter.Current(h.db).Allowed("t.tag", "t.view_count")).
 		Where("t.view_count >= ?", minViewCount).
 		Where("tr.related_tag_id NOT IN ?", srcIDs).
 		Group("t.id, t.tag, t.post_count").
 		Having("COUNT(DISTINCT tr.tag_id) >= ?", minCoverage)
 
 	if err := qb.Find(&srows).Error; err != nil {
-		zap.L().Error("Failed to query related tags (smart)", zap.Error(err))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch related tags"})
+		return nil, err
 	}
 
 	scoredRows := scoreRelatedTags(srows, len(srcIDs))
@@ -538,32 +582,7 @@ func (h *TagHandler) GetRelatedTags(c *fiber.Ctx) error {
 		return scoredRows[i].FinalScore > scoredRows[j].FinalScore
 	})
 
-	if limit > len(scoredRows) {
-		limit = len(scoredRows)
-	}
-	out := scoredRows[:limit]
-
-	resp := make([]map[string]any, 0, len(out))
-	for _, r := range out {
-		resp = append(resp, map[string]any{
-			"id":         r.ID,
-			"tag":        r.Tag,
-			"normScore":  r.NormAvg,
-			"coverage":   r.Coverage,
-			"finalScore": r.FinalScore,
-			"score":      r.FinalScore, // Back-compat: keep 'score'
-		})
-	}
-
-	return c.JSON(fiber.Map{
-		"tags":         resp,
-		"source":       "computed",
-		"mode":         mode,
-		"windowDays":   windowDays,
-		"minViewCount": minViewCount,
-		"minCoverage":  minCoverage,
-		"usedTagIds":   srcIDs,
-	})
+	return scoredRows, nil
 }
 
 func timeToUnixPtr(t *time.Time) *int64 {
@@ -574,8 +593,12 @@ func timeToUnixPtr(t *time.Time) *int64 {
 }
 
 func sanitizeTagSortBy(sortBy string) string {
-	if sortBy == "ratio" {
-		return "ratio"
+	switch sortBy {
+	case "ratio", "heat":
+		return sortBy
+	}
+	if _, ok := tagChangeSorts[sortBy]; ok {
+		return sortBy
 	}
 	return "rank"
 }
@@ -587,7 +610,7 @@ func sanitizeSortOrder(sortOrder string) string {
 	return "asc"
 }
 
-func parseRequestedTags(tagsParam string) ([]string, bool) {
+func parseRequestedTags(rules *tagfilter.Rules, tagsParam string) ([]string, bool) {
 	if tagsParam == "" {
 		return nil, false
 	}
@@ -596,7 +619,7 @@ func parseRequestedTags(tagsParam string) ([]string, bool) {
 	filtered := make([]string, 0, len(parts))
 	for _, part := range parts {
 		tag := strings.TrimSpace(part)
-		if tag != "" && !utils.TagNameHasPlus(tag) {
+		if tag != "" && !rules.ExcludesName(tag) {
 			filtered = append(filtered, tag)
 		}
 	}
@@ -604,12 +627,12 @@ func parseRequestedTags(tagsParam string) ([]string, bool) {
 	return filtered, len(filtered) == 0
 }
 
-func validateRequestedTag(tag string) string {
+func validateRequestedTag(rules *tagfilter.Rules, tag string) string {
 	if tag == "" {
 		return "Tag is required"
 	}
-	if utils.TagNameHasPlus(tag) {
-		return "Tags containing '+' are not supported"
+	if rules.ExcludesName(tag) {
+		return "Tag is excluded by the tag filter rules"
 	}
 	return ""
 }
@@ -633,20 +656,21 @@ func resolveTagSearch(search string, targetTags []string) (string, []string) {
 
 func applyTagFilters(
 	query *gorm.DB,
+	rules *tagfilter.Rules,
 	search string,
 	targetTags []string,
 	requestedTagsFilteredOut bool,
 ) *gorm.DB {
-	query = query.Where("tag NOT LIKE ?", "%+%")
+	query = query.Scopes(rules.Allowed("tags.tag", "tags.view_count"))
 
 	if requestedTagsFilteredOut {
 		return query.Where("1 = 0")
 	}
 	if len(targetTags) > 0 {
-		return query.Where("tag IN ?", targetTags)
+		return query.Where("tags.tag IN ?", targetTags)
 	}
 	if search != "" {
-		return query.Where("tag LIKE ?", "%"+search+"%")
+		return query.Where("tags.tag LIKE ?", "%"+search+"%")
 	}
 
 	return query
@@ -715,6 +739,7 @@ func buildTagWithHistory(
 	tag models.Tag,
 	snapshots map[string]models.TagHistory,
 	history []models.TagHistory,
+	rangeStarts map[string]models.TagHistory,
 	endDate *time.Time,
 ) TagWithHistory {
 	metrics := buildTagMetrics(tag, snapshots, endDate)
@@ -725,9 +750,12 @@ func buildTagWithHistory(
 		PostCount:            metrics.PostCount,
 		Ratio:                metrics.Ratio,
 		Rank:                 tag.Rank,
-		Heat:                 0,
+		Heat:                 tag.Heat,
 		FanslyCreatedAt:      ptr(timeToUnix(tag.FanslyCreatedAt)),
 		LastCheckedAt:        timeToUnixPtr(tag.LastCheckedAt),
+		NextRefreshAt:        timeToUnixPtr(tag.NextRefreshAt),
+		IsWatched:            tag.IsWatched,
+		Source:               tag.Source,
 		LastUsedForDiscovery: timeToUnixPtr(tag.LastUsedForDiscovery),
 		IsDeleted:            tag.IsDeleted,
 		DeletedDetectedAt:    timeToUnixPtr(tag.DeletedDetectedAt),
@@ -739,6 +767,10 @@ func buildTagWithHistory(
 	if len(history) > 0 {
 		tagWithHistory.TotalChange = history[0].ViewCount - history[len(history)-1].ViewCount
 	}
+	if rangeStarts != nil {
+		start, ok := rangeStarts[tag.ID]
+		tagWithHistory.RangeChange = ptr(buildTagChange(metrics, start, ok))
+	}
 
 	return tagWithHistory
 }
@@ -747,13 +779,14 @@ func buildTagsWithHistory(
 	tags []models.Tag,
 	snapshots map[string]models.TagHistory,
 	historyByTag map[string][]models.TagHistory,
+	rangeStarts map[string]models.TagHistory,
 	endDate *time.Time,
 ) []TagWithHistory {
 	tagsWithHistory := make([]TagWithHistory, 0, len(tags))
 	for _, tag := range tags {
 		tagsWithHistory = append(
 			tagsWithHistory,
-			buildTagWithHistory(tag, snapshots, historyByTag[tag.ID], endDate),
+			buildTagWithHistory(tag, snapshots, historyByTag[tag.ID], rangeStarts, endDate),
 		)
 	}
 
@@ -763,6 +796,7 @@ func buildTagsWithHistory(
 func buildTagData(
 	tags []models.Tag,
 	snapshots map[string]models.TagHistory,
+	rangeStarts map[string]models.TagHistory,
 	endDate *time.Time,
 ) []map[string]any {
 	tagsData := make([]map[string]any, len(tags))
@@ -775,15 +809,22 @@ func buildTagData(
 			"postCount":            metrics.PostCount,
 			"ratio":                metrics.Ratio,
 			"rank":                 tag.Rank,
-			"heat":                 0,
+			"heat":                 tag.Heat,
 			"fanslyCreatedAt":      ptr(timeToUnix(tag.FanslyCreatedAt)),
 			"lastCheckedAt":        timeToUnixPtr(tag.LastCheckedAt),
+			"nextRefreshAt":        timeToUnixPtr(tag.NextRefreshAt),
+			"isWatched":            tag.IsWatched,
+			"source":               tag.Source,
 			"lastUsedForDiscovery": timeToUnixPtr(tag.LastUsedForDiscovery),
 			"isDeleted":            tag.IsDeleted,
 			"deletedDetectedAt":    timeToUnixPtr(tag.DeletedDetectedAt),
 			"createdAt":            tag.CreatedAt.Unix(),
 			"updatedAt":            tag.UpdatedAt.Unix(),
 		}
+		if rangeStarts != nil {
+			start, ok := rangeStarts[tag.ID]
+			tagsData[i]["rangeChange"] = buildTagChange(metrics, start, ok)
+		}
 	}
 
 	return tagsData
@@ -793,13 +834,19 @@ func (h *TagHandler) applyTagSort(
 	query *gorm.DB,
 	sortOptions tagSortOptions,
 ) *gorm.DB {
-	if sortOptions.By != "ratio" {
+	switch sortOptions.By {
+	case "heat":
+		return query.Order("heat " + sortOptions.Order).Order("rank ASC")
+	case "rank":
 		return query.Order("rank " + sortOptions.Order)
 	}
+	if metric, ok := tagChangeSorts[sortOptions.By]; ok {
+		return query.Order(orderByExpr(sortOptions.Exprs[metric], sortOptions.Order, "rank ASC"))
+	}
 
+	// The snapshot is joined by the caller for a past range end
 	orderClause := currentTagRatioOrderClause()
 	if sortOptions.EndDate != nil {
-		query = query.Joins(latestTagSnapshotJoinForTagsClause(), *sortOptions.EndDate)
 		orderClause = snapshotTagRatioOrderClause()
 	}
 
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"ftoolbox/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultTagChangeRange is the range growth is measured over when no historyStartDate is given
+const defaultTagChangeRange = 7 * 24 * time.Hour
+
+// tagRangeMetrics are the metrics GetTags filters with min<Name>/max<Name>
+var tagRangeMetrics = []string{
+	"Views", "Posts", "Ratio", "AgeDays",
+	"ViewChange", "ViewChangePercent", "PostChange", "PostChangePercent",
+}
+
+// tagChangeSorts are the sortBy values that rank tags by growth over the range
+var tagChangeSorts = map[string]string{
+	"viewchange":        "ViewChange",
+	"viewchangepercent": "ViewChangePercent",
+	"postchange":        "PostChange",
+	"postchangepercent": "PostChangePercent",
+}
+
+// tagRange measures tag metrics at the end of the history range, and growth since the
+// oldest history point inside it. Views at the end of the range are 0 for tags deleted
+// by then, as in the response.
+type tagRange struct {
+	Start time.Time
+	End   *time.Time // nil means now
+}
+
+// tagChange is a tag's growth over a tagRange
+type tagChange struct {
+	ViewChange        int64   `json:"viewChange"`
+	ViewChangePercent float64 `json:"viewChangePercent"`
+	PostChange        int64   `json:"postChange"`
+	PostChangePercent float64 `json:"postChangePercent"`
+}
+
+// resolveTagRange returns the range growth is measured over, or nil if the request
+// neither gives a range start nor sorts or filters by growth
+func resolveTagRange(startDate, endDate *time.Time, sortBy string, bounds map[string]rangeBound) *tagRange {
+	usesChange := tagChangeSorts[sortBy] != ""
+	for _, name := range tagChangeSorts {
+		if _, ok := bounds[name]; ok {
+			usesChange = true
+		}
+	}
+	if startDate == nil && !usesChange {
+		return nil
+	}
+
+	end := time.Now()
+	if endDate != nil {
+		end = *endDate
+	}
+	start := end.Add(-defaultTagChangeRange)
+	if startDate != nil {
+		start = *startDate
+	}
+	return &tagRange{Start: start, End: endDate}
+}
+
+// join adds the oldest history point inside the range as tag_range_start
+func (r *tagRange) join(query *gorm.DB) *gorm.DB {
+	end := time.Now()
+	if r.End != nil {
+		end = *r.End
+	}
+
+	return query.Joins("LEFT JOIN tag_history AS tag_range_start ON tag_range_start.id = ("+
+		"SELECT th.id FROM tag_history AS th "+
+		"WHERE th.tag_id = tags.id AND th.created_at >= ? AND th.created_at <= ? "+
+		"ORDER BY th.created_at ASC, th.id ASC LIMIT 1)", r.Start, end)
+}
+
+// tagMetricExprs returns the SQL of each range metric. With an endDate, the latest
+// snapshot must be joined as tag_snapshots; growth needs the range joined.
+func tagMetricExprs(r *tagRange, endDate *time.Time) map[string]sqlExpr {
+	views := sqlExpr{SQL: "(CASE WHEN tags.is_deleted THEN 0 ELSE tags.view_count END)"}
+	posts := sqlExpr{SQL: "tags.post_count"}
+	ratio := sqlExpr{SQL: currentTagRatioOrderClause()}
+	if endDate != nil {
+		views = sqlExpr{
+			SQL: "(CASE WHEN tags.is_deleted AND (tags.deleted_detected_at IS NULL OR tags.deleted_detected_at <= ?) " +
+				"THEN 0 ELSE COALESCE(tag_snapshots.view_count, tags.view_count) END)",
+			Args: []any{*endDate},
+		}
+		posts = sqlExpr{SQL: "COALESCE(tag_snapshots.post_count, tags.post_count)"}
+		ratio = sqlExpr{SQL: snapshotTagRatioOrderClause()}
+	}
+
+	exprs := map[string]sqlExpr{
+		"Views":   views,
+		"Posts":   posts,
+		"Ratio":   ratio,
+		"AgeDays": {SQL: "(TIMESTAMPDIFF(SECOND, tags.fansly_created_at, ?) / 86400)", Args: []any{time.Now()}},
+	}
+	if r == nil {
+		return exprs
+	}
+
+	exprs["ViewChange"] = changeExpr(views, "tag_range_start.view_count")
+	exprs["ViewChangePercent"] = changePercentExpr(views, "tag_range_start.view_count")
+	exprs["PostChange"] = changeExpr(posts, "tag_range_start.post_count")
+	exprs["PostChangePercent"] = changePercentExpr(posts, "tag_range_start.post_count")
+	return exprs
+}
+
+// changeExpr is the end value minus the start column; 0 without a start point
+func changeExpr(end sqlExpr, startColumn string) sqlExpr {
+	return sqlExpr{
+		SQL:  "(" + end.SQL + " - COALESCE(" + startColumn + ", " + end.SQL + "))",
+		Args: append(append([]any{}, end.Args...), end.Args...),
+	}
+}
+
+// changePercentExpr is the change relative to the start column; 0 when the start is 0 or missing
+func changePercentExpr(end sqlExpr, startColumn string) sqlExpr {
+	return sqlExpr{
+		SQL: "(CASE WHEN COALESCE(" + startColumn + ", 0) > 0 THEN " +
+			"CAST(" + end.SQL + " - " + startColumn + " AS DECIMAL(30,10)) * 100 / " + startColumn +
+			" ELSE 0 END)",
+		Args: end.Args,
+	}
+}
+
+// loadTagRangeStarts returns each tag's oldest history point inside the range
+func loadTagRangeStarts(db *gorm.DB, tagIDs []string, r *tagRange) (map[string]models.TagHistory, error) {
+	starts := make(map[string]models.TagHistory, len(tagIDs))
+	if len(tagIDs) == 0 {
+		return starts, nil
+	}
+
+	var points []models.TagHistory
+	query := r.join(db.Table("tags")).
+		Select("tag_range_start.*").
+		Where("tags.id IN ?", tagIDs).
+		Where("tag_range_start.id IS NOT NULL")
+	if err := query.Scan(&points).Error; err != nil {
+		return nil, err
+	}
+
+	for _, point := range points {
+		starts[point.TagID] = point
+	}
+	return starts, nil
+}
+
+// buildTagChange computes a tag's growth from its metrics at the range end and the
+// oldest point inside the range, matching the SQL of tagMetricExprs
+func buildTagChange(metrics tagMetrics, start models.TagHistory, hasStart bool) tagChange {
+	if !hasStart {
+		return tagChange{}
+	}
+
+	var change tagChange
+	change.ViewChange, change.ViewChangePercent = countChange(metrics.ViewCount, start.ViewCount)
+	change.PostChange, change.PostChangePercent = countChange(metrics.PostCount, start.PostCount)
+	return change
+}
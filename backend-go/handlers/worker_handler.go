@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"ftoolbox/models"
+	"ftoolbox/workers"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
@@ -9,11 +13,15 @@ import (
 )
 
 type WorkerHandler struct {
-	db *gorm.DB
+	db            *gorm.DB
+	workerManager *workers.WorkerManager
 }
 
-func NewWorkerHandler(db *gorm.DB) *WorkerHandler {
-	return &WorkerHandler{db: db}
+func NewWorkerHandler(db *gorm.DB, workerManager *workers.WorkerManager) *WorkerHandler {
+	return &WorkerHandler{
+		db:            db,
+		workerManager: workerManager,
+	}
 }
 
 func (h *WorkerHandler) GetStatus(c *fiber.Ctx) error {
@@ -44,7 +52,144 @@ func (h *WorkerHandler) GetStatus(c *fiber.Ctx) error {
 		status = "running"
 	}
 
+	now := time.Now()
+	leases := make([]fiber.Map, 0, len(workers))
+	for _, w := range workers {
+		leaseActive := w.LeaseOwner != nil && w.LeaseExpiresAt != nil && w.LeaseExpiresAt.After(now)
+		leases = append(leases, fiber.Map{
+			"name":           w.Name,
+			"status":         w.Status,
+			"leaseOwner":     w.LeaseOwner,
+			"leaseExpiresAt": timeToUnixPtr(w.LeaseExpiresAt),
+			"leaseActive":    leaseActive,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  status,
+		"workers": leases,
+	})
+}
+
+func (h *WorkerHandler) ListWorkers(c *fiber.Ctx) error {
+	states, err := h.workerManager.ListWorkers()
+	if err != nil {
+		zap.L().Error("Failed to fetch workers", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch workers"})
+	}
+
+	return c.JSON(fiber.Map{
+		"instanceId":           h.workerManager.InstanceID(),
+		"workers":              states,
+		"fanslyCircuitBreaker": h.workerManager.CircuitBreakerStats(),
+		"fanslyRateLimit":      h.workerManager.RateLimitStats(),
+	})
+}
+
+func (h *WorkerHandler) StartWorker(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if err := h.workerManager.Start(name); err != nil {
+		return workerControlError(c, name, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "Worker started", "worker": name})
+}
+
+func (h *WorkerHandler) StopWorker(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if err := h.workerManager.Stop(name); err != nil {
+		return workerControlError(c, name, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "Worker stopped", "worker": name})
+}
+
+func (h *WorkerHandler) RunWorker(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if err := h.workerManager.TriggerNow(name); err != nil {
+		return workerControlError(c, name, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"message": "Worker run triggered", "worker": name})
+}
+
+func (h *WorkerHandler) UpdateWorker(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	var req struct {
+		IsEnabled *bool   `json:"isEnabled"`
+		Schedule  *string `json:"schedule"`
+		JitterMs  *int64  `json:"jitterMs"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.IsEnabled == nil && req.Schedule == nil && req.JitterMs == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "isEnabled, schedule or jitterMs is required"})
+	}
+
+	if req.Schedule != nil || req.JitterMs != nil {
+		if err := h.workerManager.SetSchedule(name, req.Schedule, req.JitterMs); err != nil {
+			return workerControlError(c, name, err)
+		}
+	}
+	if req.IsEnabled != nil {
+		if err := h.workerManager.SetEnabled(name, *req.IsEnabled); err != nil {
+			return workerControlError(c, name, err)
+		}
+	}
+
+	return c.JSON(fiber.Map{"message": "Worker updated", "worker": name})
+}
+
+func workerControlError(c *fiber.Ctx, name string, err error) error {
+	switch {
+	case errors.Is(err, workers.ErrWorkerNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, workers.ErrInvalidSchedule):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, workers.ErrWorkersDisabled),
+		errors.Is(err, workers.ErrWorkerRunning),
+		errors.Is(err, workers.ErrWorkerNotRunning),
+		errors.Is(err, workers.ErrWorkerDisabled),
+		errors.Is(err, workers.ErrLeaseHeldElsewhere):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		zap.L().Error("Worker control failed", zap.String("worker", name), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Worker control failed"})
+	}
+}
+
+func (h *WorkerHandler) GetWorkerRuns(c *fiber.Ctx) error {
+	name := c.Params("name")
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	var worker models.Worker
+	if err := h.db.Where("name = ?", name).First(&worker).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Worker not found"})
+		}
+		zap.L().Error("Failed to fetch worker", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch worker"})
+	}
+
+	runs, total, err := h.workerManager.GetRuns(name, limit, (page-1)*limit)
+	if err != nil {
+		zap.L().Error("Failed to fetch worker runs", zap.String("worker", name), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch worker runs"})
+	}
+
 	return c.JSON(fiber.Map{
-		"status": status,
+		"worker":     name,
+		"runs":       runs,
+		"pagination": buildPagination(page, limit, total),
 	})
 }
@@ -72,8 +72,6 @@ func main() {
 		}
 	}
 
-	// Heat calculation removed; backend will always return heat = 0
-
 	// Calculate initial ranks for creators if needed
 	var creatorCount int64
 	db.Model(&models.Creator{}).Where("rank IS NULL").Count(&creatorCount)
@@ -86,6 +84,10 @@ func main() {
 
 	// Initialize Fansly client with global rate limiting
 	fanslyClient := fansly.NewClient()
+	if cfg.FanslyBaseURL != "" {
+		fanslyClient.SetBaseURL(cfg.FanslyBaseURL)
+		zap.L().Info("Using custom Fansly API base URL", zap.String("base_url", cfg.FanslyBaseURL))
+	}
 
 	// Configure global rate limit
 	fanslyClient.SetGlobalRateLimit(cfg.GlobalRateLimit, cfg.GlobalRateLimitWindow)
@@ -93,16 +95,22 @@ func main() {
 		zap.Int("max_requests", cfg.GlobalRateLimit),
 		zap.Int("window_seconds", cfg.GlobalRateLimitWindow))
 
-	// Initialize worker manager
-	workerManager := workers.NewWorkerManager(db, cfg.WorkerEnabled)
+	fanslyClient.SetCircuitBreaker(cfg.FanslyBreakerThreshold, time.Duration(cfg.FanslyBreakerCooldown)*time.Millisecond)
+
+	// Initialize worker manager; replicas coordinate through per-worker leases
+	workerManager := workers.NewWorkerManager(db, cfg)
+	zap.L().Info("Worker manager instance", zap.String("instance_id", workerManager.InstanceID()))
+	workerManager.SetCircuitBreaker(fanslyClient.CircuitBreaker())
+	workerManager.SetRateLimitStats(fanslyClient.GetRateLimitStats)
 
 	// Register workers
 	tagUpdater := workers.NewTagUpdaterWorker(db, cfg, fanslyClient)
 	tagDiscovery := workers.NewTagDiscoveryWorker(db, cfg, fanslyClient)
 	rankCalculator := workers.NewRankCalculatorWorker(db, cfg)
-	creatorUpdater := workers.NewCreatorUpdaterWorker(db, fanslyClient)
+	creatorUpdater := workers.NewCreatorUpdaterWorker(db, cfg, fanslyClient)
 	statisticsCalculator := workers.NewStatisticsCalculatorWorker(db, cfg)
 	tagCleanup := workers.NewTagCleanupWorker(db, cfg)
+	heatCalculator := workers.NewHeatCalculatorWorker(db, cfg)
 
 	if err := workerManager.Register(tagUpdater); err != nil {
 		zap.L().Error("Failed to register tag updater", zap.Error(err))
@@ -122,6 +130,14 @@ func main() {
 	if err := workerManager.Register(tagCleanup); err != nil {
 		zap.L().Error("Failed to register tag cleanup", zap.Error(err))
 	}
+	if err := workerManager.Register(heatCalculator); err != nil {
+		zap.L().Error("Failed to register heat calculator", zap.Error(err))
+	}
+
+	// Reset workers and runs left running by instances that stopped mid-run
+	if err := workerManager.ReconcileStaleRuns(); err != nil {
+		zap.L().Error("Failed to reconcile stale worker runs", zap.Error(err))
+	}
 
 	// Start workers if enabled
 	if cfg.WorkerEnabled {
@@ -144,6 +160,9 @@ func main() {
 			if err := workerManager.Start("tag-cleanup"); err != nil {
 				zap.L().Error("Failed to start tag cleanup", zap.Error(err))
 			}
+			if err := workerManager.Start("heat-calculator"); err != nil {
+				zap.L().Error("Failed to start heat calculator", zap.Error(err))
+			}
 		}()
 	}
 
@@ -188,7 +207,7 @@ func main() {
 		},
 	}))
 
-	routes.Setup(app, db, workerManager, fanslyClient)
+	routes.Setup(app, cfg, db, workerManager, fanslyClient)
 
 	zap.L().Info("Server starting", zap.String("port", cfg.Port))
 	if err := app.Listen(":" + cfg.Port); err != nil {
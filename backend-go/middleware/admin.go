@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminAuth requires the configured admin API key as a bearer token or X-Admin-Key header.
+// Admin routes are unavailable when no key is configured.
+func AdminAuth(apiKey string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if apiKey == "" {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Admin API is not configured"})
+		}
+
+		provided := c.Get("X-Admin-Key")
+		if provided == "" {
+			provided = strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(apiKey)) != 1 {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		}
+
+		return c.Next()
+	}
+}
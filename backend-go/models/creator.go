@@ -15,6 +15,8 @@ type Creator struct {
 	VideoCount        int64      `gorm:"not null;column:video_count" json:"videoCount"`
 	Rank              *int       `gorm:"column:rank;index" json:"rank"`
 	LastCheckedAt     *time.Time `gorm:"column:last_checked_at" json:"-"`
+	NextRefreshAt     *time.Time `gorm:"column:next_refresh_at;index" json:"-"`
+	IsRequested       bool       `gorm:"not null;default:false;column:is_requested" json:"isRequested"`
 	IsDeleted         bool       `gorm:"not null;default:false;column:is_deleted" json:"isDeleted"`
 	DeletedDetectedAt *time.Time `gorm:"column:deleted_detected_at" json:"deletedDetectedAt"`
 	CreatedAt         time.Time  `gorm:"not null;column:created_at;default:CURRENT_TIMESTAMP" json:"-"`
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// CreatorRankDaily stores a creator's rank from the first rank calculation of each day
+type CreatorRankDaily struct {
+	CreatorID  string    `gorm:"primaryKey;type:varchar(255);column:creator_id" json:"creatorId"`
+	BucketDate time.Time `gorm:"primaryKey;type:date;column:bucket_date;index:idx_creator_ranks_daily_bucket" json:"bucketDate"`
+	Rank       int       `gorm:"not null;column:rank" json:"rank"`
+}
+
+func (CreatorRankDaily) TableName() string {
+	return "creator_ranks_daily"
+}
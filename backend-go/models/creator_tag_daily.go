@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// CreatorTagDaily counts a creator's posts per tag, bucketed by the day the post was made
+type CreatorTagDaily struct {
+	CreatorID  string    `gorm:"primaryKey;type:varchar(255);column:creator_id;index:idx_ctd_creator_bucket,priority:1" json:"creatorId"`
+	TagID      string    `gorm:"primaryKey;type:varchar(255);column:tag_id;index:idx_ctd_tag_bucket,priority:1" json:"tagId"`
+	BucketDate time.Time `gorm:"primaryKey;type:date;column:bucket_date;index:idx_ctd_creator_bucket,priority:2;index:idx_ctd_tag_bucket,priority:2" json:"bucketDate"`
+	PostCount  int64     `gorm:"not null;default:0;column:post_count" json:"postCount"`
+	LastSeenAt time.Time `gorm:"not null;column:last_seen_at;default:CURRENT_TIMESTAMP" json:"lastSeenAt"`
+}
+
+func (CreatorTagDaily) TableName() string {
+	return "creator_tags_daily"
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// DiscoveryFrontier holds the candidate source tags for tag discovery and how
+// worthwhile each has been to explore
+type DiscoveryFrontier struct {
+	Tag          string     `gorm:"primaryKey;type:varchar(255);column:tag" json:"tag"`
+	IsSeed       bool       `gorm:"not null;default:false;column:is_seed;index" json:"isSeed"`
+	Novelty      float64    `gorm:"not null;default:0;column:novelty" json:"novelty"` // moving average of new tags found per page
+	Score        float64    `gorm:"not null;default:0;column:score;index" json:"score"`
+	Runs         int        `gorm:"not null;default:0;column:runs" json:"runs"`
+	PagesRead    int        `gorm:"not null;default:0;column:pages_read" json:"pagesRead"`
+	TagsFound    int        `gorm:"not null;default:0;column:tags_found" json:"tagsFound"`
+	NewTagsFound int        `gorm:"not null;default:0;column:new_tags_found" json:"newTagsFound"`
+	LastUsedAt   *time.Time `gorm:"column:last_used_at;index" json:"lastUsedAt,omitempty"`
+	CreatedAt    time.Time  `gorm:"not null;column:created_at;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt    time.Time  `gorm:"not null;column:updated_at;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (DiscoveryFrontier) TableName() string {
+	return "discovery_frontier"
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// DiscoveryProgress records how far discovery has paged through a source tag's
+// suggestions, so the next run continues where the previous one stopped
+type DiscoveryProgress struct {
+	TagID         string     `gorm:"primaryKey;type:varchar(255);column:tag_id" json:"tagId"`
+	Cursor        string     `gorm:"not null;default:'';type:varchar(64);column:cursor" json:"cursor"` // "before" cursor: oldest suggestion ID read in this pass
+	Offset        int        `gorm:"not null;default:0;column:offset" json:"offset"`
+	UseOffset     bool       `gorm:"not null;default:false;column:use_offset" json:"useOffset"`
+	PagesRead     int        `gorm:"not null;default:0;column:pages_read" json:"pagesRead"`
+	PostsRead     int        `gorm:"not null;default:0;column:posts_read" json:"postsRead"`
+	PassesDone    int        `gorm:"not null;default:0;column:passes_done" json:"passesDone"`
+	PassStartedAt *time.Time `gorm:"column:pass_started_at" json:"passStartedAt,omitempty"`
+	LastPageAt    *time.Time `gorm:"column:last_page_at" json:"lastPageAt,omitempty"`
+	CreatedAt     time.Time  `gorm:"not null;column:created_at;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt     time.Time  `gorm:"not null;column:updated_at;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (DiscoveryProgress) TableName() string {
+	return "discovery_progress"
+}
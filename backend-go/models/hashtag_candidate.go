@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Hashtag candidate statuses
+const (
+	HashtagPending  = "pending"   // not looked up yet
+	HashtagTracked  = "tracked"   // resolved and stored as a tag
+	HashtagNotFound = "not_found" // no such tag on Fansly
+	HashtagExcluded = "excluded"  // exists but excluded by the tag filter rules
+)
+
+// HashtagCandidate is a hashtag seen in post content that isn't a tracked tag yet
+type HashtagCandidate struct {
+	Tag           string     `gorm:"primaryKey;type:varchar(255);column:tag" json:"tag"`
+	Status        string     `gorm:"not null;default:'pending';type:varchar(16);column:status;index:idx_hashtag_candidates_status_mentions,priority:1" json:"status"`
+	Mentions      int64      `gorm:"not null;default:0;column:mentions;index:idx_hashtag_candidates_status_mentions,priority:2,sort:desc" json:"mentions"`
+	LastCheckedAt *time.Time `gorm:"column:last_checked_at" json:"lastCheckedAt"`
+	CreatedAt     time.Time  `gorm:"not null;column:created_at;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt     time.Time  `gorm:"not null;column:updated_at;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (HashtagCandidate) TableName() string {
+	return "hashtag_candidates"
+}
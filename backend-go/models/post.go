@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+type Post struct {
+	ID                  string     `gorm:"primaryKey;type:varchar(255);column:id" json:"id"`
+	AccountID           string     `gorm:"not null;type:varchar(255);column:account_id;index:idx_posts_account_likes,priority:1" json:"accountId"`
+	Content             string     `gorm:"type:text;column:content" json:"content"`
+	LikeCount           int64      `gorm:"not null;default:0;column:like_count;index;index:idx_posts_account_likes,priority:2" json:"likeCount"`
+	ReplyCount          int64      `gorm:"not null;default:0;column:reply_count" json:"replyCount"`
+	MediaLikeCount      int64      `gorm:"not null;default:0;column:media_like_count" json:"mediaLikeCount"`
+	TotalTipAmount      int64      `gorm:"not null;default:0;column:total_tip_amount" json:"totalTipAmount"`
+	TipAmount           int64      `gorm:"not null;default:0;column:tip_amount" json:"tipAmount"`
+	AttachmentTipAmount int64      `gorm:"not null;default:0;column:attachment_tip_amount" json:"attachmentTipAmount"`
+	AttachmentCount     int        `gorm:"not null;default:0;column:attachment_count" json:"attachmentCount"`
+	FanslyCreatedAt     time.Time  `gorm:"not null;column:fansly_created_at;index" json:"fanslyCreatedAt"`
+	ExpiresAt           *time.Time `gorm:"column:expires_at" json:"expiresAt"`
+	LastSeenAt          time.Time  `gorm:"not null;column:last_seen_at;default:CURRENT_TIMESTAMP" json:"lastSeenAt"`
+	CreatedAt           time.Time  `gorm:"not null;column:created_at;default:CURRENT_TIMESTAMP" json:"-"`
+	UpdatedAt           time.Time  `gorm:"not null;column:updated_at;default:CURRENT_TIMESTAMP" json:"-"`
+}
+
+func (Post) TableName() string {
+	return "posts"
+}
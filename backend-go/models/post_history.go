@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+type PostHistory struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	PostID         string    `gorm:"not null;type:varchar(255);column:post_id;index:idx_post_history_post_created,priority:1" json:"postId"`
+	LikeCount      int64     `gorm:"not null;column:like_count" json:"likeCount"`
+	ReplyCount     int64     `gorm:"not null;column:reply_count" json:"replyCount"`
+	MediaLikeCount int64     `gorm:"not null;column:media_like_count" json:"mediaLikeCount"`
+	TotalTipAmount int64     `gorm:"not null;column:total_tip_amount" json:"totalTipAmount"`
+	CreatedAt      time.Time `gorm:"not null;column:created_at;default:CURRENT_TIMESTAMP;index;index:idx_post_history_post_created,priority:2,sort:desc" json:"-"`
+	UpdatedAt      time.Time `gorm:"not null;column:updated_at;default:CURRENT_TIMESTAMP" json:"-"`
+}
+
+func (PostHistory) TableName() string {
+	return "post_history"
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PostTag links a post to the tags its media offer suggestion carried
+type PostTag struct {
+	PostID    string    `gorm:"primaryKey;type:varchar(255);column:post_id" json:"postId"`
+	TagID     string    `gorm:"primaryKey;type:varchar(255);column:tag_id;index" json:"tagId"`
+	CreatedAt time.Time `gorm:"not null;column:created_at;default:CURRENT_TIMESTAMP" json:"-"`
+}
+
+func (PostTag) TableName() string {
+	return "post_tags"
+}
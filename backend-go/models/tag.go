@@ -13,6 +13,9 @@ type Tag struct {
 	Heat                 float64    `gorm:"not null;default:0;column:heat;index" json:"heat"`
 	FanslyCreatedAt      time.Time  `gorm:"not null;column:fansly_created_at" json:"-"`
 	LastCheckedAt        *time.Time `gorm:"column:last_checked_at" json:"-"`
+	NextRefreshAt        *time.Time `gorm:"column:next_refresh_at;index" json:"-"`
+	IsWatched            bool       `gorm:"not null;default:false;column:is_watched" json:"isWatched"`
+	Source               string     `gorm:"not null;default:'suggestions';type:varchar(32);column:source" json:"source"`
 	LastUsedForDiscovery *time.Time `gorm:"column:last_used_for_discovery" json:"-"`
 	IsDeleted            bool       `gorm:"not null;default:false;column:is_deleted;index:idx_tags_is_deleted_deleted,priority:1" json:"isDeleted"`
 	DeletedDetectedAt    *time.Time `gorm:"column:deleted_detected_at;index:idx_tags_is_deleted_deleted,priority:2" json:"deletedDetectedAt"`
@@ -20,6 +23,13 @@ type Tag struct {
 	UpdatedAt            time.Time  `gorm:"not null;column:updated_at;default:CURRENT_TIMESTAMP;index:idx_tags_updated_at" json:"-"`
 }
 
+// Tag sources record how a tag was first found
+const (
+	TagSourceSuggestions = "suggestions" // post tags on discovery suggestions
+	TagSourceRequest     = "request"     // requested through the API
+	TagSourceContent     = "content"     // hashtags in post content
+)
+
 func (Tag) TableName() string {
 	return "tags"
 }
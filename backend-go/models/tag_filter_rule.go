@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Tag filter rule types
+const (
+	TagFilterSubstring = "substring" // exclude tags containing Value
+	TagFilterRegex     = "regex"     // exclude tags matching the regular expression in Value
+	TagFilterMinViews  = "min_views" // exclude tags with fewer than MinViews views
+	TagFilterBlocklist = "blocklist" // exclude the tag named Value
+	TagFilterAllowlist = "allowlist" // keep the tag named Value regardless of other rules
+)
+
+// TagFilterRule is one admin-editable rule deciding which tags are tracked
+type TagFilterRule struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	Type      string    `gorm:"not null;type:varchar(32);column:type" json:"type"`
+	Value     string    `gorm:"not null;default:'';type:varchar(255);column:value" json:"value"`
+	MinViews  int64     `gorm:"not null;default:0;column:min_views" json:"minViews"`
+	IsEnabled bool      `gorm:"not null;default:true;column:is_enabled" json:"isEnabled"`
+	Note      string    `gorm:"not null;default:'';type:varchar(255);column:note" json:"note"`
+	CreatedAt time.Time `gorm:"not null;column:created_at;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"not null;column:updated_at;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (TagFilterRule) TableName() string {
+	return "tag_filter_rules"
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// TagRankDaily stores a tag's rank from the first rank calculation of each day
+type TagRankDaily struct {
+	TagID      string    `gorm:"primaryKey;type:varchar(255);column:tag_id" json:"tagId"`
+	BucketDate time.Time `gorm:"primaryKey;type:date;column:bucket_date;index:idx_tag_ranks_daily_bucket" json:"bucketDate"`
+	Rank       int       `gorm:"not null;column:rank" json:"rank"`
+}
+
+func (TagRankDaily) TableName() string {
+	return "tag_ranks_daily"
+}
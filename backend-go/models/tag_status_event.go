@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// TagStatusEvent records a tag disappearing from Fansly (banned) or coming back (unbanned)
+type TagStatusEvent struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	TagID     string    `gorm:"not null;type:varchar(255);column:tag_id;index:idx_tag_status_events_tag_created,priority:1" json:"tagId"`
+	Event     string    `gorm:"not null;type:varchar(16);column:event" json:"event"`
+	CreatedAt time.Time `gorm:"not null;column:created_at;default:CURRENT_TIMESTAMP;index:idx_tag_status_events_tag_created,priority:2" json:"createdAt"`
+}
+
+// Tag status events
+const (
+	TagEventBanned   = "banned"
+	TagEventUnbanned = "unbanned"
+)
+
+func (TagStatusEvent) TableName() string {
+	return "tag_status_events"
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+type WorkerRun struct {
+	ID             uint       `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	WorkerName     string     `gorm:"not null;type:varchar(255);column:worker_name;index:idx_worker_runs_worker_started,priority:1" json:"workerName"`
+	InstanceID     string     `gorm:"not null;type:varchar(255);column:instance_id" json:"instanceId"`
+	Status         string     `gorm:"not null;default:'running';column:status" json:"status"` // running, success, failed, timeout
+	Error          *string    `gorm:"type:text;column:error" json:"error,omitempty"`
+	StartedAt      time.Time  `gorm:"not null;column:started_at;index;index:idx_worker_runs_worker_started,priority:2,sort:desc" json:"startedAt"`
+	FinishedAt     *time.Time `gorm:"column:finished_at" json:"finishedAt,omitempty"`
+	DurationMs     int64      `gorm:"not null;default:0;column:duration_ms" json:"durationMs"`
+	ItemsProcessed int        `gorm:"not null;default:0;column:items_processed" json:"itemsProcessed"`
+	ItemsCreated   int        `gorm:"not null;default:0;column:items_created" json:"itemsCreated"`
+	ItemsDeleted   int        `gorm:"not null;default:0;column:items_deleted" json:"itemsDeleted"`
+	ItemsFailed    int        `gorm:"not null;default:0;column:items_failed" json:"itemsFailed"`
+	APICalls       int64      `gorm:"not null;default:0;column:api_calls" json:"apiCalls"`
+	ItemsPerMinute float64    `gorm:"-" json:"itemsPerMinute"` // throughput of a finished run
+	CreatedAt      time.Time  `gorm:"not null;column:created_at;default:CURRENT_TIMESTAMP" json:"-"`
+	UpdatedAt      time.Time  `gorm:"not null;column:updated_at;default:CURRENT_TIMESTAMP" json:"-"`
+}
+
+func (WorkerRun) TableName() string {
+	return "worker_runs"
+}
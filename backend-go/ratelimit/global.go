@@ -2,109 +2,334 @@ package ratelimit
 
 import (
 	"context"
+	"math"
+	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
 )
 
-// GlobalRateLimiter enforces a global rate limit across all endpoints
+const (
+	// decreaseFactor is applied to the effective limit after a 429
+	decreaseFactor = 0.5
+	// minLimitFraction bounds how far the effective limit can shrink
+	minLimitFraction = 0.1
+	// defaultRetryAfter is used when a 429 carries no usable Retry-After header
+	defaultRetryAfter = 30 * time.Second
+)
+
+// GlobalRateLimiter enforces a global rate limit across all endpoints.
+// The effective limit adapts to upstream responses (AIMD): it is halved after a 429
+// and grows back by one request per window while responses succeed.
+// Waiting callers are served by priority (see Priority), with a reserved share
+// of recent slots for lower classes so they cannot starve.
 type GlobalRateLimiter struct {
 	mu                sync.Mutex
 	maxRequests       int
+	minRequests       float64
+	effectiveRequests float64
 	window            time.Duration
 	requestTimestamps []time.Time
+	blockedUntil      time.Time
+	lastAdjustedAt    time.Time
+	throttleCount     int64
+	serverLimit       int
+	serverRemaining   int
+	queues            [numPriorities][]*waiter
+	recentGrants      []Priority
+	grantCounts       [numPriorities]int64
+	timer             *time.Timer
 	logger            *zap.Logger
 }
 
+type waiter struct {
+	priority Priority
+	ready    chan struct{}
+	granted  bool
+}
+
+// recentGrantsWindow is how many past slots reserved shares are measured over
+const recentGrantsWindow = 50
+
 // NewGlobalRateLimiter creates a new global rate limiter
 func NewGlobalRateLimiter(maxRequests int, windowSeconds int, logger *zap.Logger) *GlobalRateLimiter {
+	if maxRequests < 1 {
+		maxRequests = 1
+	}
+
 	return &GlobalRateLimiter{
 		maxRequests:       maxRequests,
+		minRequests:       math.Max(1, float64(maxRequests)*minLimitFraction),
+		effectiveRequests: float64(maxRequests),
 		window:            time.Duration(windowSeconds) * time.Second,
 		requestTimestamps: make([]time.Time, 0),
+		lastAdjustedAt:    time.Now(),
+		serverLimit:       -1,
+		serverRemaining:   -1,
+		recentGrants:      make([]Priority, 0, recentGrantsWindow),
 		logger:            logger,
 	}
 }
 
-// Wait blocks until a request can be made within the global rate limit
+// Wait blocks until a request can be made within the global rate limit.
+// The caller's priority is read from ctx (see WithPriority).
 func (g *GlobalRateLimiter) Wait(ctx context.Context) error {
+	w := &waiter{
+		priority: PriorityFromContext(ctx),
+		ready:    make(chan struct{}),
+	}
+
+	g.mu.Lock()
+	g.queues[w.priority] = append(g.queues[w.priority], w)
+	g.dispatchLocked()
+	g.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		// The slot may have been granted while we were being cancelled
+		if w.granted {
+			return nil
+		}
+		g.removeWaiterLocked(w)
+		return ctx.Err()
+	}
+}
+
+// dispatchLocked grants slots to queued waiters while capacity allows and
+// schedules itself to run again when the next slot frees up.
+func (g *GlobalRateLimiter) dispatchLocked() {
+	for g.waitingLocked() > 0 {
+		now := time.Now()
+		nextAt := g.nextSlotLocked(now)
+		if nextAt.After(now) {
+			g.scheduleDispatchLocked(nextAt.Sub(now))
+			return
+		}
+
+		priority := g.selectPriorityLocked()
+		w := g.queues[priority][0]
+		g.queues[priority] = g.queues[priority][1:]
+
+		g.requestTimestamps = append(g.requestTimestamps, now)
+		g.recordGrantLocked(priority)
+		w.granted = true
+		close(w.ready)
+	}
+}
+
+// nextSlotLocked returns when the next request may be sent, honoring upstream blocks,
+// the effective limit and even spacing of requests across the window
+func (g *GlobalRateLimiter) nextSlotLocked(now time.Time) time.Time {
+	g.pruneTimestamps(now)
+	limit := g.currentLimit()
+	nextAt := now
+
+	if g.blockedUntil.After(nextAt) {
+		nextAt = g.blockedUntil
+	}
+
+	if len(g.requestTimestamps) >= limit {
+		// Wait until enough of the oldest requests expire
+		freeAt := g.requestTimestamps[len(g.requestTimestamps)-limit].Add(g.window)
+		if freeAt.After(nextAt) {
+			nextAt = freeAt
+		}
+	}
+
+	if limit > 1 && len(g.requestTimestamps) > 0 {
+		idealSpacing := g.window / time.Duration(limit)
+		spacedAt := g.requestTimestamps[len(g.requestTimestamps)-1].Add(idealSpacing)
+		if spacedAt.After(nextAt) {
+			nextAt = spacedAt
+		}
+	}
+
+	if nextAt.After(now) {
+		g.logger.Debug("Global rate limit reached, waiting",
+			zap.Int("current_requests", len(g.requestTimestamps)),
+			zap.Int("effective_max_requests", limit),
+			zap.Int("max_requests", g.maxRequests),
+			zap.Int("waiting", g.waitingLocked()),
+			zap.Duration("window", g.window),
+			zap.Duration("wait_time", nextAt.Sub(now)))
+	}
+
+	return nextAt
+}
+
+// selectPriorityLocked picks the class to serve next: a lower class that has fallen
+// below its reserved share goes first, otherwise the highest waiting class wins
+func (g *GlobalRateLimiter) selectPriorityLocked() Priority {
+	for p := Priority(numPriorities - 1); p >= 0; p-- {
+		if len(g.queues[p]) == 0 || reservedShares[p] == 0 {
+			continue
+		}
+		if g.recentShareLocked(p) < reservedShares[p] && g.hasHigherWaiterLocked(p) {
+			return p
+		}
+	}
+
+	for p := Priority(0); p < numPriorities; p++ {
+		if len(g.queues[p]) > 0 {
+			return p
+		}
+	}
+
+	return PriorityScheduled
+}
+
+func (g *GlobalRateLimiter) hasHigherWaiterLocked(priority Priority) bool {
+	for p := Priority(0); p < priority; p++ {
+		if len(g.queues[p]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *GlobalRateLimiter) recentShareLocked(priority Priority) float64 {
+	if len(g.recentGrants) == 0 {
+		return 0
+	}
+
+	count := 0
+	for _, p := range g.recentGrants {
+		if p == priority {
+			count++
+		}
+	}
+	return float64(count) / float64(len(g.recentGrants))
+}
+
+func (g *GlobalRateLimiter) recordGrantLocked(priority Priority) {
+	if len(g.recentGrants) >= recentGrantsWindow {
+		g.recentGrants = g.recentGrants[1:]
+	}
+	g.recentGrants = append(g.recentGrants, priority)
+	g.grantCounts[priority]++
+}
+
+func (g *GlobalRateLimiter) scheduleDispatchLocked(delay time.Duration) {
+	if g.timer != nil {
+		g.timer.Stop()
+	}
+	g.timer = time.AfterFunc(delay, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.dispatchLocked()
+	})
+}
+
+func (g *GlobalRateLimiter) removeWaiterLocked(w *waiter) {
+	queue := g.queues[w.priority]
+	for i, queued := range queue {
+		if queued == w {
+			g.queues[w.priority] = append(queue[:i], queue[i+1:]...)
+			return
+		}
+	}
+}
+
+func (g *GlobalRateLimiter) waitingLocked() int {
+	total := 0
+	for _, queue := range g.queues {
+		total += len(queue)
+	}
+	return total
+}
+
+// ObserveResponse adjusts the shared budget from an upstream response.
+// A 429 halves the effective limit and blocks all callers for Retry-After;
+// successful responses grow the limit back by one request per window.
+func (g *GlobalRateLimiter) ObserveResponse(statusCode int, header http.Header) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	now := time.Now()
-	cutoff := now.Add(-g.window)
+	g.observeRateLimitHeaders(now, header)
 
-	// Clean old timestamps
-	validTimestamps := make([]time.Time, 0, len(g.requestTimestamps))
-	for _, ts := range g.requestTimestamps {
-		if ts.After(cutoff) {
-			validTimestamps = append(validTimestamps, ts)
+	if statusCode == http.StatusTooManyRequests {
+		retryAfter, ok := ParseRetryAfter(header, now)
+		if !ok {
+			retryAfter = defaultRetryAfter
 		}
+		g.block(now.Add(retryAfter))
+
+		previous := g.effectiveRequests
+		g.effectiveRequests = math.Max(g.minRequests, g.effectiveRequests*decreaseFactor)
+		g.lastAdjustedAt = now
+		g.throttleCount++
+
+		g.logger.Warn("Upstream rate limited, shrinking global budget",
+			zap.Float64("previous_limit", previous),
+			zap.Float64("effective_limit", g.effectiveRequests),
+			zap.Duration("retry_after", retryAfter))
+		return
 	}
-	g.requestTimestamps = validTimestamps
 
-	// Check if we're at the limit
-	if len(g.requestTimestamps) >= g.maxRequests {
-		// Calculate wait time - we need to wait until the oldest request expires
-		oldestRequest := g.requestTimestamps[0]
-		waitTime := oldestRequest.Add(g.window).Sub(now)
-
-		if waitTime > 0 {
-			g.logger.Debug("Global rate limit reached, waiting",
-				zap.Int("current_requests", len(g.requestTimestamps)),
-				zap.Int("max_requests", g.maxRequests),
-				zap.Duration("window", g.window),
-				zap.Duration("wait_time", waitTime))
-
-			g.mu.Unlock()
-			select {
-			case <-time.After(waitTime):
-			case <-ctx.Done():
-				return ctx.Err()
-			}
-			g.mu.Lock()
-
-			// Re-clean after waiting
-			now = time.Now()
-			cutoff = now.Add(-g.window)
-			validTimestamps = validTimestamps[:0]
-			for _, ts := range g.requestTimestamps {
-				if ts.After(cutoff) {
-					validTimestamps = append(validTimestamps, ts)
-				}
-			}
-			g.requestTimestamps = validTimestamps
-		}
+	if statusCode < 200 || statusCode >= 300 {
+		return
+	}
+
+	if g.effectiveRequests < float64(g.maxRequests) && now.Sub(g.lastAdjustedAt) >= g.window {
+		g.effectiveRequests = math.Min(float64(g.maxRequests), g.effectiveRequests+1)
+		g.lastAdjustedAt = now
+
+		g.logger.Debug("Growing global budget",
+			zap.Float64("effective_limit", g.effectiveRequests),
+			zap.Int("max_requests", g.maxRequests))
+	}
+}
+
+// observeRateLimitHeaders records X-RateLimit-* headers and blocks callers
+// until the reset time once the upstream reports no remaining requests.
+func (g *GlobalRateLimiter) observeRateLimitHeaders(now time.Time, header http.Header) {
+	if limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit")); err == nil {
+		g.serverLimit = limit
+	}
+
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
 	}
+	g.serverRemaining = remaining
+
+	if remaining > 0 {
+		return
+	}
+
+	if reset, ok := parseRateLimitReset(header.Get("X-RateLimit-Reset"), now); ok {
+		g.block(reset)
+	}
+}
+
+func (g *GlobalRateLimiter) block(until time.Time) {
+	if until.After(g.blockedUntil) {
+		g.blockedUntil = until
+	}
+}
 
-	// Record this request
-	g.requestTimestamps = append(g.requestTimestamps, now)
-
-	// Calculate delay to spread requests evenly
-	if g.maxRequests > 1 && len(g.requestTimestamps) > 1 {
-		idealSpacing := g.window / time.Duration(g.maxRequests)
-		lastRequest := g.requestTimestamps[len(g.requestTimestamps)-2]
-		timeSinceLastRequest := now.Sub(lastRequest)
-
-		if timeSinceLastRequest < idealSpacing {
-			spreadDelay := idealSpacing - timeSinceLastRequest
-			g.logger.Debug("Spreading requests",
-				zap.Duration("ideal_spacing", idealSpacing),
-				zap.Duration("actual_spacing", timeSinceLastRequest),
-				zap.Duration("delay", spreadDelay))
-
-			g.mu.Unlock()
-			select {
-			case <-time.After(spreadDelay):
-			case <-ctx.Done():
-				return ctx.Err()
-			}
-			g.mu.Lock()
+func (g *GlobalRateLimiter) pruneTimestamps(now time.Time) {
+	cutoff := now.Add(-g.window)
+
+	validTimestamps := g.requestTimestamps[:0]
+	for _, ts := range g.requestTimestamps {
+		if ts.After(cutoff) {
+			validTimestamps = append(validTimestamps, ts)
 		}
 	}
+	g.requestTimestamps = validTimestamps
+}
 
-	return nil
+func (g *GlobalRateLimiter) currentLimit() int {
+	return max(1, int(g.effectiveRequests))
 }
 
 // GetStats returns current statistics
@@ -122,10 +347,78 @@ func (g *GlobalRateLimiter) GetStats() map[string]any {
 		}
 	}
 
-	return map[string]any{
-		"max_requests":    g.maxRequests,
-		"window":          g.window.String(),
-		"active_requests": activeRequests,
-		"capacity_used":   float64(activeRequests) / float64(g.maxRequests) * 100,
+	limit := g.currentLimit()
+	stats := map[string]any{
+		"max_requests":           g.maxRequests,
+		"effective_max_requests": limit,
+		"effective_rate_per_sec": float64(limit) / g.window.Seconds(),
+		"window":                 g.window.String(),
+		"active_requests":        activeRequests,
+		"capacity_used":          float64(activeRequests) / float64(limit) * 100,
+		"throttle_count":         g.throttleCount,
+	}
+
+	waiting := make(map[string]int, numPriorities)
+	granted := make(map[string]int64, numPriorities)
+	for p := Priority(0); p < numPriorities; p++ {
+		waiting[p.String()] = len(g.queues[p])
+		granted[p.String()] = g.grantCounts[p]
+	}
+	stats["waiting"] = waiting
+	stats["granted"] = granted
+
+	if g.blockedUntil.After(now) {
+		stats["blocked_until"] = g.blockedUntil.Unix()
+	}
+	if g.serverLimit >= 0 {
+		stats["server_limit"] = g.serverLimit
+	}
+	if g.serverRemaining >= 0 {
+		stats["server_remaining"] = g.serverRemaining
+	}
+
+	return stats
+}
+
+// ParseRetryAfter reads a Retry-After header given either in seconds or as an HTTP date
+func ParseRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(0, date.Sub(now)), true
+	}
+
+	return 0, false
+}
+
+// parseRateLimitReset accepts either a unix timestamp or a number of seconds until reset
+func parseRateLimitReset(value string, now time.Time) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	reset, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || reset < 0 {
+		return time.Time{}, false
+	}
+
+	// Values this large are epoch timestamps (seconds or milliseconds), not deltas
+	switch {
+	case reset > 1e12:
+		return time.UnixMilli(reset), true
+	case reset > 1e9:
+		return time.Unix(reset, 0), true
+	default:
+		return now.Add(time.Duration(reset) * time.Second), true
 	}
 }
@@ -0,0 +1,53 @@
+package ratelimit
+
+import "context"
+
+// Priority decides the order in which waiting callers get global rate limit slots
+type Priority int
+
+const (
+	// PriorityInteractive is for requests a user is actively waiting on
+	PriorityInteractive Priority = iota
+	// PriorityScheduled is for periodic refreshes of tracked data
+	PriorityScheduled
+	// PriorityBulk is for background crawling such as discovery
+	PriorityBulk
+
+	numPriorities = 3
+)
+
+// reservedShares is the minimum fraction of recent slots each class is entitled to
+// while it has waiters, so lower classes keep making progress under load
+var reservedShares = [numPriorities]float64{
+	PriorityInteractive: 0,
+	PriorityScheduled:   0.2,
+	PriorityBulk:        0.1,
+}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityScheduled:
+		return "scheduled"
+	case PriorityBulk:
+		return "bulk"
+	default:
+		return "unknown"
+	}
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose rate-limited requests use the given priority
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFromContext returns the priority carried by ctx, defaulting to PriorityScheduled
+func PriorityFromContext(ctx context.Context) Priority {
+	if priority, ok := ctx.Value(priorityKey{}).(Priority); ok && priority >= 0 && priority < numPriorities {
+		return priority
+	}
+	return PriorityScheduled
+}
@@ -1,8 +1,10 @@
 package routes
 
 import (
+	"ftoolbox/config"
 	"ftoolbox/fansly"
 	"ftoolbox/handlers"
+	"ftoolbox/middleware"
 	"ftoolbox/workers"
 	"time"
 
@@ -11,12 +13,16 @@ import (
 	"gorm.io/gorm"
 )
 
-func Setup(app *fiber.App, db *gorm.DB, workerManager *workers.WorkerManager, fanslyClient *fansly.Client) {
+func Setup(app *fiber.App, cfg *config.Config, db *gorm.DB, workerManager *workers.WorkerManager, fanslyClient fansly.API) {
 	api := app.Group("/api")
 
 	tagHandler := handlers.NewTagHandler(db, fanslyClient)
 	creatorHandler := handlers.NewCreatorHandler(db, fanslyClient)
-	workerHandler := handlers.NewWorkerHandler(db)
+	workerHandler := handlers.NewWorkerHandler(db, workerManager)
+	postHandler := handlers.NewPostHandler(db)
+	creatorTagHandler := handlers.NewCreatorTagHandler(db)
+	discoveryHandler := handlers.NewDiscoveryHandler(db)
+	tagFilterHandler := handlers.NewTagFilterHandler(db)
 
 	// Tag routes
 	api.Get("/tags", tagHandler.GetTags)
@@ -31,6 +37,10 @@ func Setup(app *fiber.App, db *gorm.DB, workerManager *workers.WorkerManager, fa
 		},
 	}))
 	api.Post("/tags/request", tagHandler.RequestTag)
+	api.Get("/tags/by-name/:tag", tagHandler.GetTagByName)
+	api.Get("/tags/:id", tagHandler.GetTag)
+	api.Get("/tags/:id/posts", postHandler.GetTagPosts)
+	api.Get("/tags/:id/creators", creatorTagHandler.GetTagCreators)
 
 	// Creator routes
 	api.Get("/creators", creatorHandler.GetCreators)
@@ -43,10 +53,30 @@ func Setup(app *fiber.App, db *gorm.DB, workerManager *workers.WorkerManager, fa
 		},
 	}))
 	api.Post("/creators/request", creatorHandler.RequestCreator)
+	api.Get("/creators/by-username/:username", creatorHandler.GetCreatorByUsername)
+	api.Get("/creators/:id", creatorHandler.GetCreator)
+	api.Get("/creators/:id/posts", postHandler.GetCreatorPosts)
+	api.Get("/creators/:id/tags", creatorTagHandler.GetCreatorTags)
 
 	// Worker routes
 	api.Get("/workers/status", workerHandler.GetStatus)
 
+	// Admin routes
+	admin := api.Group("/admin", middleware.AdminAuth(cfg.AdminAPIKey))
+	admin.Get("/workers", workerHandler.ListWorkers)
+	admin.Get("/workers/:name/runs", workerHandler.GetWorkerRuns)
+	admin.Post("/workers/:name/start", workerHandler.StartWorker)
+	admin.Post("/workers/:name/stop", workerHandler.StopWorker)
+	admin.Post("/workers/:name/run", workerHandler.RunWorker)
+	admin.Patch("/workers/:name", workerHandler.UpdateWorker)
+	admin.Get("/discovery/frontier", discoveryHandler.GetFrontier)
+	admin.Post("/discovery/seeds", discoveryHandler.AddSeeds)
+	admin.Delete("/discovery/seeds/:tag", discoveryHandler.RemoveSeed)
+	admin.Get("/tag-filters", tagFilterHandler.ListRules)
+	admin.Post("/tag-filters", tagFilterHandler.CreateRule)
+	admin.Patch("/tag-filters/:id", tagFilterHandler.UpdateRule)
+	admin.Delete("/tag-filters/:id", tagFilterHandler.DeleteRule)
+
 	// Health check
 	api.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "ok"})
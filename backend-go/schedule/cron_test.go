@@ -0,0 +1,135 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseCronRejectsMalformed(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"-1 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"a * * * *",
+		"*/0 * * * *",
+		"*/-5 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1- * * * *",
+		"-5 * * * *",
+		"1,,2 * * * *",
+		"1-70 * * * *",
+		"*/5/2 * * * *",
+	}
+
+	for _, spec := range specs {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestParseCronFields(t *testing.T) {
+	bits := func(values ...int) uint64 {
+		var b uint64
+		for _, v := range values {
+			b |= 1 << uint(v)
+		}
+		return b
+	}
+
+	tests := []struct {
+		spec   string
+		minute uint64
+		hour   uint64
+		dow    uint64
+	}{
+		{"0 0 * * *", bits(0), bits(0), bits(0, 1, 2, 3, 4, 5, 6, 7)},
+		{"59 23 * * 0", bits(59), bits(23), bits(0)},
+		{"*/15 */6 * * *", bits(0, 15, 30, 45), bits(0, 6, 12, 18), bits(0, 1, 2, 3, 4, 5, 6, 7)},
+		{"10-30/10 9-11 * * 1-5", bits(10, 20, 30), bits(9, 10, 11), bits(1, 2, 3, 4, 5)},
+		{"5/20 0 * * *", bits(5, 25, 45), bits(0), bits(0, 1, 2, 3, 4, 5, 6, 7)},
+		{"1,2,40-42 0,12 * * 6,7", bits(1, 2, 40, 41, 42), bits(0, 12), bits(0, 6, 7)},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) = %v", tt.spec, err)
+		}
+		if c.minute != tt.minute {
+			t.Errorf("ParseCron(%q) minutes = %b, want %b", tt.spec, c.minute, tt.minute)
+		}
+		if c.hour != tt.hour {
+			t.Errorf("ParseCron(%q) hours = %b, want %b", tt.spec, c.hour, tt.hour)
+		}
+		if c.dow != tt.dow {
+			t.Errorf("ParseCron(%q) days of week = %b, want %b", tt.spec, c.dow, tt.dow)
+		}
+		if c.String() != tt.spec {
+			t.Errorf("String() = %q, want %q", c.String(), tt.spec)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2026-10-17 is a Saturday
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", date(2026, 10, 17, 10, 0), date(2026, 10, 17, 10, 1)},
+		{"strictly after", "30 10 * * *", date(2026, 10, 17, 10, 30), date(2026, 10, 18, 10, 30)},
+		{"seconds dropped", "* * * * *", time.Date(2026, 10, 17, 10, 0, 59, 999, time.UTC), date(2026, 10, 17, 10, 1)},
+		{"minute step", "*/15 * * * *", date(2026, 10, 17, 10, 16), date(2026, 10, 17, 10, 30)},
+		{"range step", "10-30/10 * * * *", date(2026, 10, 17, 10, 30), date(2026, 10, 17, 11, 10)},
+		{"hour rollover", "0 * * * *", date(2026, 10, 17, 23, 59), date(2026, 10, 18, 0, 0)},
+		{"day rollover", "15 3 * * *", date(2026, 10, 17, 4, 0), date(2026, 10, 18, 3, 15)},
+		{"month rollover", "0 0 1 * *", date(2026, 10, 31, 23, 59), date(2026, 11, 1, 0, 0)},
+		{"year rollover", "0 0 1 1 *", date(2026, 12, 31, 23, 59), date(2027, 1, 1, 0, 0)},
+		{"december to january", "0 0 * 1 *", date(2026, 10, 17, 0, 0), date(2027, 1, 1, 0, 0)},
+		{"short month skipped", "0 0 30 * *", date(2027, 1, 30, 0, 0), date(2027, 3, 30, 0, 0)},
+		{"31st skips 30-day months", "0 0 31 * *", date(2026, 10, 31, 0, 0), date(2026, 12, 31, 0, 0)},
+		{"leap day", "0 0 29 2 *", date(2026, 10, 17, 0, 0), date(2028, 2, 29, 0, 0)},
+		{"day of month only", "0 0 13 * *", date(2026, 10, 17, 0, 0), date(2026, 11, 13, 0, 0)},
+		{"day of week only", "0 12 * * 1", date(2026, 10, 17, 0, 0), date(2026, 10, 19, 12, 0)},
+		{"sunday as 0", "0 0 * * 0", date(2026, 10, 17, 0, 0), date(2026, 10, 18, 0, 0)},
+		{"sunday as 7", "0 0 * * 7", date(2026, 10, 17, 0, 0), date(2026, 10, 18, 0, 0)},
+		{"weekdays", "0 9 * * 1-5", date(2026, 10, 16, 9, 0), date(2026, 10, 19, 9, 0)},
+		// Both day fields restricted: either one matching is enough
+		{"either day: weekday first", "0 0 13 * 5", date(2026, 10, 17, 0, 0), date(2026, 10, 23, 0, 0)},
+		{"either day: date first", "0 0 19 * 5", date(2026, 10, 17, 0, 0), date(2026, 10, 19, 0, 0)},
+		{"either day within month", "0 0 1 2 1", date(2026, 10, 17, 0, 0), date(2027, 2, 1, 0, 0)},
+		{"either day within month later", "0 0 1 2 1", date(2027, 2, 1, 0, 0), date(2027, 2, 8, 0, 0)},
+		{"local time converted", "0 0 * * *", time.Date(2026, 10, 17, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*3600)),
+			date(2026, 10, 19, 0, 0)},
+		{"never fires", "0 0 31 2 *", date(2026, 10, 17, 0, 0), time.Time{}},
+		{"never fires in april", "0 0 31 4 *", date(2026, 10, 17, 0, 0), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) = %v", tt.spec, err)
+			}
+			if got := c.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) of %q = %s, want %s", tt.from, tt.spec, got, tt.want)
+			}
+		})
+	}
+}
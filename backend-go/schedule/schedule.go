@@ -0,0 +1,99 @@
+package schedule
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a worker runs next
+type Schedule interface {
+	// Next returns the first run time strictly after t
+	Next(t time.Time) time.Time
+}
+
+// Interval runs at a fixed distance from the previous run
+type Interval struct {
+	Every time.Duration
+}
+
+func (s Interval) Next(t time.Time) time.Time {
+	return t.Add(s.Every)
+}
+
+// Jittered delays every run of a schedule by a random amount below Jitter,
+// so replicas and restarts do not fire at the same instant
+type Jittered struct {
+	Schedule Schedule
+	Jitter   time.Duration
+}
+
+func (s Jittered) Next(t time.Time) time.Time {
+	next := s.Schedule.Next(t)
+	if next.IsZero() {
+		return next
+	}
+	return next.Add(Jitter(s.Jitter))
+}
+
+// Jitter returns a random delay in [0, maxDelay), or 0 if maxDelay is not positive
+func Jitter(maxDelay time.Duration) time.Duration {
+	if maxDelay <= 0 {
+		return 0
+	}
+	return rand.N(maxDelay)
+}
+
+// WithJitter wraps s in Jittered when jitter is positive
+func WithJitter(s Schedule, jitter time.Duration) Schedule {
+	if jitter <= 0 {
+		return s
+	}
+	return Jittered{Schedule: s, Jitter: jitter}
+}
+
+// Parse accepts a 5-field cron expression (minute hour day-of-month month day-of-week,
+// evaluated in UTC), one of @yearly, @monthly, @weekly, @daily and @hourly, or
+// "@every <duration>" such as "@every 10m".
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("empty schedule")
+	}
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every duration: %w", err)
+		}
+		if every <= 0 {
+			return nil, fmt.Errorf("@every duration must be positive")
+		}
+		return Interval{Every: every}, nil
+	}
+
+	switch spec {
+	case "@yearly", "@annually":
+		spec = "0 0 1 1 *"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@hourly":
+		spec = "0 * * * *"
+	}
+
+	return ParseCron(spec)
+}
+
+// IsCron reports whether s fires at wall-clock times rather than at an interval
+func IsCron(s Schedule) bool {
+	if jittered, ok := s.(Jittered); ok {
+		s = jittered.Schedule
+	}
+	_, ok := s.(*Cron)
+	return ok
+}
@@ -0,0 +1,74 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	from := date(2026, 10, 17, 10, 30)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"@every 10m", date(2026, 10, 17, 10, 40)},
+		{"  @every 1h30m ", date(2026, 10, 17, 12, 0)},
+		{"@hourly", date(2026, 10, 17, 11, 0)},
+		{"@daily", date(2026, 10, 18, 0, 0)},
+		{"@midnight", date(2026, 10, 18, 0, 0)},
+		{"@weekly", date(2026, 10, 18, 0, 0)},
+		{"@monthly", date(2026, 11, 1, 0, 0)},
+		{"@yearly", date(2027, 1, 1, 0, 0)},
+		{"@annually", date(2027, 1, 1, 0, 0)},
+		{"*/20 * * * *", date(2026, 10, 17, 10, 40)},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v", tt.spec, err)
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Parse(%q).Next(%s) = %s, want %s", tt.spec, from, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejectsMalformed(t *testing.T) {
+	specs := []string{"", "  ", "@every", "@every 0s", "@every -1m", "@every often", "@fortnightly", "* * *"}
+
+	for _, spec := range specs {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestJittered(t *testing.T) {
+	from := date(2026, 10, 17, 10, 30)
+	hourly, _ := Parse("@hourly")
+	s := WithJitter(hourly, time.Minute)
+
+	if !IsCron(s) {
+		t.Fatal("IsCron(jittered cron) = false")
+	}
+	for range 100 {
+		next := s.Next(from)
+		if next.Before(date(2026, 10, 17, 11, 0)) || !next.Before(date(2026, 10, 17, 11, 1)) {
+			t.Fatalf("Next = %s, want within a minute after 11:00", next)
+		}
+	}
+
+	never, _ := Parse("0 0 31 2 *")
+	if next := WithJitter(never, time.Minute).Next(from); !next.IsZero() {
+		t.Errorf("Next of a schedule that never fires = %s, want zero", next)
+	}
+
+	if _, ok := WithJitter(hourly, 0).(*Cron); !ok {
+		t.Error("WithJitter without jitter wrapped the schedule")
+	}
+	if IsCron(Interval{Every: time.Minute}) {
+		t.Error("IsCron(Interval) = true")
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"fmt"
+	"ftoolbox/fansly"
+	"ftoolbox/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// creatorBatchSize bounds the rows per multi-row insert when storing creators
+const creatorBatchSize = 100
+
+// CreatorService stores creators fetched from Fansly. The API and the workers use it
+// so a creator is stored the same way whichever of them saw it first.
+type CreatorService struct {
+	db *gorm.DB
+}
+
+func NewCreatorService(db *gorm.DB) *CreatorService {
+	return &CreatorService{db: db}
+}
+
+// CreatorEntry is a fresh Fansly account to store, with the time of its next refresh
+type CreatorEntry struct {
+	Account     *fansly.FanslyAccount
+	NextRefresh time.Time
+	Requested   bool // asked for by a user
+}
+
+// CreatorDisplayName is the account's display name, or its username if it has none
+func CreatorDisplayName(account *fansly.FanslyAccount) string {
+	if account.DisplayName == "" {
+		return account.Username
+	}
+	return account.DisplayName
+}
+
+// Find loads the stored creators with the given IDs, keyed by ID
+func (s *CreatorService) Find(ids []string) (map[string]models.Creator, error) {
+	creators := make(map[string]models.Creator, len(ids))
+	if len(ids) == 0 {
+		return creators, nil
+	}
+
+	var rows []models.Creator
+	if err := s.db.Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load creators: %w", err)
+	}
+	for _, row := range rows {
+		creators[row.ID] = row
+	}
+	return creators, nil
+}
+
+// Store upserts the creators and appends one history point each, with multi-row
+// INSERT ... ON DUPLICATE KEY UPDATE statements in a single transaction. Stored
+// creators get the fresh counts and schedule and lose their deletion mark; their
+// rank is kept and they stay requested once requested. Accounts repeated in
+// entries are stored once. It returns the rows as written.
+func (s *CreatorService) Store(entries []CreatorEntry) ([]models.Creator, error) {
+	now := time.Now()
+	seen := make(map[string]struct{}, len(entries))
+	creators := make([]models.Creator, 0, len(entries))
+	history := make([]models.CreatorHistory, 0, len(entries))
+
+	for _, entry := range entries {
+		account := entry.Account
+		if account == nil || account.ID == "" {
+			continue
+		}
+		if _, ok := seen[account.ID]; ok {
+			continue
+		}
+		seen[account.ID] = struct{}{}
+
+		displayName := CreatorDisplayName(account)
+		nextRefresh := entry.NextRefresh
+		creators = append(creators, models.Creator{
+			ID:            account.ID,
+			Username:      account.Username,
+			DisplayName:   &displayName,
+			MediaLikes:    account.AccountMediaLikes,
+			PostLikes:     account.PostLikes,
+			Followers:     account.FollowCount,
+			ImageCount:    account.TimelineStats.ImageCount,
+			VideoCount:    account.TimelineStats.VideoCount,
+			LastCheckedAt: &now,
+			NextRefreshAt: &nextRefresh,
+			IsRequested:   entry.Requested,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+		history = append(history, models.CreatorHistory{
+			CreatorID:  account.ID,
+			MediaLikes: account.AccountMediaLikes,
+			PostLikes:  account.PostLikes,
+			Followers:  account.FollowCount,
+			ImageCount: account.TimelineStats.ImageCount,
+			VideoCount: account.TimelineStats.VideoCount,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		})
+	}
+
+	if len(creators) == 0 {
+		return creators, nil
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		updates := clause.AssignmentColumns([]string{
+			"username", "display_name", "media_likes", "post_likes", "followers",
+			"image_count", "video_count", "last_checked_at", "next_refresh_at", "updated_at",
+		})
+		updates = append(updates, clause.Assignments(map[string]any{
+			"is_requested":        gorm.Expr("is_requested OR VALUES(is_requested)"),
+			"is_deleted":          false,
+			"deleted_detected_at": nil,
+		})...)
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: updates,
+		}).CreateInBatches(&creators, creatorBatchSize).Error; err != nil {
+			return fmt.Errorf("failed to upsert creators: %w", err)
+		}
+
+		if err := tx.CreateInBatches(&history, creatorBatchSize).Error; err != nil {
+			return fmt.Errorf("failed to create creator history: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return creators, nil
+}
@@ -0,0 +1,329 @@
+// Package services holds the write paths shared by the HTTP handlers and the
+// background workers, so a record looks the same whichever of them stored it.
+package services
+
+import (
+	"fmt"
+	"ftoolbox/fansly"
+	"ftoolbox/models"
+	"ftoolbox/tagfilter"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InitialRefreshDelay is when a new tag is first refreshed. Its initial history
+// point is then old enough for the refresh scheduler to measure its growth.
+const InitialRefreshDelay = time.Hour
+
+// TagService creates tags and records refreshes and deletions. Every method is
+// safe to call concurrently for the same tag, from any replica.
+type TagService struct {
+	db *gorm.DB
+}
+
+func NewTagService(db *gorm.DB) *TagService {
+	return &TagService{db: db}
+}
+
+// TagCreateOptions describe how a new tag was found
+type TagCreateOptions struct {
+	Source  string // one of the models.TagSource* values
+	Watched bool   // requested by a user
+}
+
+// Create stores a tag seen on Fansly with an initial history point, its source and a
+// provisional rank. If the tag is already stored it is returned unchanged with
+// created=false; concurrent calls for the same tag create it once.
+func (s *TagService) Create(fanslyTag *fansly.FanslyTag, opts TagCreateOptions) (*models.Tag, bool, error) {
+	if fanslyTag == nil || fanslyTag.ID == "" {
+		return nil, false, fmt.Errorf("tag has no ID")
+	}
+
+	now := time.Now()
+	nextRefresh := now.Add(InitialRefreshDelay)
+	tag := models.Tag{
+		ID:              fanslyTag.ID,
+		Tag:             fanslyTag.Tag,
+		ViewCount:       fanslyTag.ViewCount,
+		PostCount:       fanslyTag.PostCount,
+		FanslyCreatedAt: fansly.ParseFanslyTimestamp(fanslyTag.CreatedAt),
+		LastCheckedAt:   &now,
+		NextRefreshAt:   &nextRefresh,
+		IsWatched:       opts.Watched,
+		Source:          opts.Source,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if tag.Source == "" {
+		tag.Source = models.TagSourceSuggestions
+	}
+
+	created := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tag)
+		if res.Error != nil {
+			return fmt.Errorf("failed to create tag: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+
+		history := models.TagHistory{
+			TagID:     tag.ID,
+			ViewCount: tag.ViewCount,
+			PostCount: tag.PostCount,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return fmt.Errorf("failed to create tag history: %w", err)
+		}
+
+		return assignProvisionalRank(tx, &tag)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if created {
+		return &tag, true, nil
+	}
+
+	// Someone else stored it first; return their row
+	var existing models.Tag
+	if err := s.db.Where("id = ? OR tag = ?", tag.ID, tag.Tag).First(&existing).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to load existing tag: %w", err)
+	}
+	return &existing, false, nil
+}
+
+// assignProvisionalRank places a new tag among the ranked tags the way the rank
+// calculator would, without renumbering the others; the next rank run settles it.
+// Tags the filter rules exclude stay unranked.
+func assignProvisionalRank(tx *gorm.DB, tag *models.Tag) error {
+	rules := tagfilter.Current(tx)
+	if rules.Excludes(tag.Tag, tag.ViewCount) {
+		return nil
+	}
+
+	var ahead int64
+	if err := tx.Model(&models.Tag{}).
+		Where("id <> ?", tag.ID).
+		Where("rank IS NOT NULL").
+		Where("CASE WHEN is_deleted THEN 0 ELSE view_count END >= ?", tag.ViewCount).
+		Count(&ahead).Error; err != nil {
+		return fmt.Errorf("failed to rank tag: %w", err)
+	}
+
+	rank := int(ahead) + 1
+	if err := tx.Model(&models.Tag{}).Where("id = ?", tag.ID).UpdateColumn("rank", rank).Error; err != nil {
+		return fmt.Errorf("failed to rank tag: %w", err)
+	}
+	tag.Rank = &rank
+	return nil
+}
+
+// tagBatchSize bounds the rows per multi-row statement when refreshing tags
+const tagBatchSize = 100
+
+// TagRefresh is fresh data from Fansly for a stored tag, with the time of its next refresh
+type TagRefresh struct {
+	Tag         *models.Tag
+	Fresh       *fansly.FanslyTag
+	NextRefresh time.Time
+}
+
+// Refresh records fresh counts for a stored tag: it clears a deletion mark with an
+// unbanned event, appends a history point with the change since the stored counts
+// and schedules the next refresh. tag is updated in place.
+func (s *TagService) Refresh(tag *models.Tag, fresh *fansly.FanslyTag, nextRefresh time.Time) error {
+	return s.RefreshBatch([]TagRefresh{{Tag: tag, Fresh: fresh, NextRefresh: nextRefresh}})
+}
+
+// RefreshBatch refreshes many tags the way Refresh does, with one locking read, one
+// multi-row tag update and one multi-row history insert per batch. The tag rows are
+// locked while the changes are computed, so concurrent refreshes don't double count.
+// Tags that no longer exist are skipped. Refreshed tags are updated in place.
+func (s *TagService) RefreshBatch(refreshes []TagRefresh) error {
+	for start := 0; start < len(refreshes); start += tagBatchSize {
+		end := min(start+tagBatchSize, len(refreshes))
+		if err := s.refreshBatch(refreshes[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TagService) refreshBatch(refreshes []TagRefresh) error {
+	ids := make([]string, len(refreshes))
+	for i, refresh := range refreshes {
+		ids[i] = refresh.Tag.ID
+	}
+
+	var rows []models.Tag
+	var targets []*models.Tag
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var locked []models.Tag
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", ids).
+			Order("id").
+			Find(&locked).Error; err != nil {
+			return fmt.Errorf("failed to lock tags: %w", err)
+		}
+		current := make(map[string]models.Tag, len(locked))
+		for _, tag := range locked {
+			current[tag.ID] = tag
+		}
+
+		now := time.Now()
+		rows = make([]models.Tag, 0, len(refreshes))
+		targets = make([]*models.Tag, 0, len(refreshes))
+		history := make([]models.TagHistory, 0, len(refreshes))
+		var events []models.TagStatusEvent
+		for _, refresh := range refreshes {
+			tag, ok := current[refresh.Tag.ID]
+			if !ok {
+				continue
+			}
+			if tag.IsDeleted {
+				zap.L().Info("Tag exists again on Fansly, clearing deleted status",
+					zap.String("tag", tag.Tag))
+				events = append(events, models.TagStatusEvent{
+					TagID:     tag.ID,
+					Event:     models.TagEventUnbanned,
+					CreatedAt: now,
+				})
+			}
+
+			history = append(history, models.TagHistory{
+				TagID:           tag.ID,
+				ViewCount:       refresh.Fresh.ViewCount,
+				Change:          refresh.Fresh.ViewCount - tag.ViewCount,
+				PostCount:       refresh.Fresh.PostCount,
+				PostCountChange: refresh.Fresh.PostCount - tag.PostCount,
+				CreatedAt:       now,
+				UpdatedAt:       now,
+			})
+
+			nextRefresh := refresh.NextRefresh
+			tag.ViewCount = refresh.Fresh.ViewCount
+			tag.PostCount = refresh.Fresh.PostCount
+			tag.IsDeleted = false
+			tag.DeletedDetectedAt = nil
+			tag.LastCheckedAt = &now
+			tag.NextRefreshAt = &nextRefresh
+			tag.UpdatedAt = now
+			rows = append(rows, tag)
+			targets = append(targets, refresh.Tag)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		// Every row exists and is locked, so the upsert only ever updates
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"view_count", "post_count", "is_deleted", "deleted_detected_at",
+				"last_checked_at", "next_refresh_at", "updated_at",
+			}),
+		}).Create(&rows).Error; err != nil {
+			return fmt.Errorf("failed to update tags: %w", err)
+		}
+
+		if err := tx.Create(&history).Error; err != nil {
+			return fmt.Errorf("failed to create history: %w", err)
+		}
+		if len(events) > 0 {
+			if err := tx.Create(&events).Error; err != nil {
+				return fmt.Errorf("failed to record unbanned tags: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, target := range targets {
+		*target = rows[i]
+	}
+	return nil
+}
+
+// MarkDeleted records that the named tag no longer exists on Fansly and reports
+// whether this call was the one to notice, which also records a banned event. No
+// history point is written. If nextRefresh is set, the tag also counts as checked now.
+func (s *TagService) MarkDeleted(name string, nextRefresh *time.Time) (bool, error) {
+	now := time.Now()
+	name = strings.TrimSpace(name)
+
+	var deleted bool
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Tag{}).
+			Where("tag = ? AND is_deleted = ?", name, false).
+			Updates(map[string]any{
+				"is_deleted":          true,
+				"deleted_detected_at": now,
+				"updated_at":          now,
+			})
+		if res.Error != nil {
+			return fmt.Errorf("failed to mark tag as deleted: %w", res.Error)
+		}
+		deleted = res.RowsAffected > 0
+		if !deleted {
+			return nil
+		}
+
+		if err := tx.Exec(
+			"INSERT INTO tag_status_events (tag_id, event, created_at) SELECT id, ?, ? FROM tags WHERE tag = ?",
+			models.TagEventBanned, now, name,
+		).Error; err != nil {
+			return fmt.Errorf("failed to record banned tag: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if nextRefresh != nil {
+		if err := s.db.Model(&models.Tag{}).Where("tag = ?", name).Updates(map[string]any{
+			"last_checked_at": now,
+			"next_refresh_at": *nextRefresh,
+		}).Error; err != nil {
+			return deleted, fmt.Errorf("failed to schedule deleted tag: %w", err)
+		}
+	}
+
+	if deleted {
+		zap.L().Info("Tag no longer exists on Fansly, marking as deleted", zap.String("tag", name))
+	}
+	return deleted, nil
+}
+
+// Watch marks a tag as requested by a user and makes it due for a refresh now.
+// Tags already watched are left alone.
+func (s *TagService) Watch(tag *models.Tag) error {
+	if tag.IsWatched {
+		return nil
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&models.Tag{}).
+		Where("id = ? AND is_watched = ?", tag.ID, false).
+		UpdateColumns(map[string]any{
+			"is_watched":      true,
+			"next_refresh_at": now,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to mark tag as watched: %w", err)
+	}
+
+	tag.IsWatched = true
+	tag.NextRefreshAt = &now
+	return nil
+}
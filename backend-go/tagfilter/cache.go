@@ -0,0 +1,67 @@
+package tagfilter
+
+import (
+	"ftoolbox/models"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// cacheTTL bounds how long rule edits take to reach other replicas
+const cacheTTL = time.Minute
+
+var cache struct {
+	mu       sync.Mutex
+	rules    *Rules
+	loadedAt time.Time
+}
+
+// DefaultRules are the rules a new database starts with; they match the filtering
+// that was hard-coded before rules became configurable
+func DefaultRules() []models.TagFilterRule {
+	return []models.TagFilterRule{
+		{Type: models.TagFilterSubstring, Value: "+", IsEnabled: true, Note: "Combined tags"},
+		{Type: models.TagFilterSubstring, Value: "&", IsEnabled: true, Note: "Combined tags"},
+		{Type: models.TagFilterMinViews, MinViews: 500, IsEnabled: true, Note: "Too small to track"},
+	}
+}
+
+// Current returns the enabled rules, reloading them from db at most once per cacheTTL.
+// If loading fails the last loaded rules, or the defaults, stay in effect.
+func Current(db *gorm.DB) *Rules {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.rules != nil && time.Since(cache.loadedAt) < cacheTTL {
+		return cache.rules
+	}
+
+	var stored []models.TagFilterRule
+	rules, err := func() (*Rules, error) {
+		if err := db.Where("is_enabled = ?", true).Order("id").Find(&stored).Error; err != nil {
+			return nil, err
+		}
+		return Compile(stored)
+	}()
+	if err != nil {
+		zap.L().Error("Failed to load tag filter rules", zap.Error(err))
+		if cache.rules == nil {
+			cache.rules, _ = Compile(DefaultRules())
+		}
+		cache.loadedAt = time.Now()
+		return cache.rules
+	}
+
+	cache.rules = rules
+	cache.loadedAt = time.Now()
+	return rules
+}
+
+// Invalidate makes the next Current call reload the rules
+func Invalidate() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.loadedAt = time.Time{}
+}
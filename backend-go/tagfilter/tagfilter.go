@@ -0,0 +1,217 @@
+// Package tagfilter decides which tags are tracked. The rules live in the
+// tag_filter_rules table; discovery, ranking, cleanup and the API all apply them
+// through this package so they agree on what an excluded tag is.
+package tagfilter
+
+import (
+	"errors"
+	"fmt"
+	"ftoolbox/models"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidRule is returned for rules that can't be applied
+var ErrInvalidRule = errors.New("invalid tag filter rule")
+
+// inlineFlags matches inline flag groups such as (?i) or (?s:...). Regex rules are
+// always case-insensitive and run both in Go and in SQL, so they can't set flags.
+var inlineFlags = regexp.MustCompile(`\(\?-?[A-Za-z]+(-[A-Za-z]*)?[:)]`)
+
+// Rules is a compiled, read-only set of enabled filter rules
+type Rules struct {
+	allow      []string
+	block      []string
+	substrings []string
+	regexes    []*regexp.Regexp
+	minViews   int64
+}
+
+// Compile builds a rule set from rules, ignoring disabled ones
+func Compile(rules []models.TagFilterRule) (*Rules, error) {
+	r := &Rules{}
+	for _, rule := range rules {
+		if !rule.IsEnabled {
+			continue
+		}
+		if err := Validate(rule); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", rule.ID, err)
+		}
+
+		value := normalize(rule.Value)
+		switch rule.Type {
+		case models.TagFilterAllowlist:
+			r.allow = append(r.allow, value)
+		case models.TagFilterBlocklist:
+			r.block = append(r.block, value)
+		case models.TagFilterSubstring:
+			r.substrings = append(r.substrings, value)
+		case models.TagFilterRegex:
+			r.regexes = append(r.regexes, regexp.MustCompile(regexPattern(rule.Value)))
+		case models.TagFilterMinViews:
+			r.minViews = max(r.minViews, rule.MinViews)
+		}
+	}
+	return r, nil
+}
+
+// Validate checks a single rule
+func Validate(rule models.TagFilterRule) error {
+	switch rule.Type {
+	case models.TagFilterAllowlist, models.TagFilterBlocklist, models.TagFilterSubstring:
+		if normalize(rule.Value) == "" {
+			return fmt.Errorf("%w: %s rule needs a value", ErrInvalidRule, rule.Type)
+		}
+	case models.TagFilterRegex:
+		if rule.Value == "" {
+			return fmt.Errorf("%w: regex rule needs a value", ErrInvalidRule)
+		}
+		if inlineFlags.MatchString(rule.Value) {
+			return fmt.Errorf("%w: regex rules can't set inline flags", ErrInvalidRule)
+		}
+		if _, err := regexp.Compile(rule.Value); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+	case models.TagFilterMinViews:
+		if rule.MinViews <= 0 {
+			return fmt.Errorf("%w: min_views rule needs minViews > 0", ErrInvalidRule)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidRule, rule.Type)
+	}
+	return nil
+}
+
+// ValidateInDB checks a rule like Validate and also that the database accepts a
+// regex rule's pattern, since ExcludedSQL runs it as a REGEXP there
+func ValidateInDB(db *gorm.DB, rule models.TagFilterRule) error {
+	if err := Validate(rule); err != nil {
+		return err
+	}
+	if rule.Type != models.TagFilterRegex {
+		return nil
+	}
+
+	var matched bool
+	if err := db.Raw("SELECT '' REGEXP ?", regexPattern(rule.Value)).Scan(&matched).Error; err != nil {
+		return fmt.Errorf("%w: the database rejects the regex: %v", ErrInvalidRule, err)
+	}
+	return nil
+}
+
+// regexPattern is the pattern a regex rule runs as, in Go and in SQL. The explicit
+// (?i) makes SQL matching case-insensitive whatever the column collation.
+func regexPattern(value string) string {
+	return "(?i)" + value
+}
+
+// ExcludesName reports whether a tag is excluded by its name alone, for tags whose
+// view count isn't known yet
+func (r *Rules) ExcludesName(tag string) bool {
+	tag = normalize(tag)
+	if tag == "" {
+		return true
+	}
+	if contains(r.allow, tag) {
+		return false
+	}
+	if contains(r.block, tag) {
+		return true
+	}
+	for _, s := range r.substrings {
+		if strings.Contains(tag, s) {
+			return true
+		}
+	}
+	for _, re := range r.regexes {
+		if re.MatchString(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Excludes reports whether a tag with the given view count is excluded
+func (r *Rules) Excludes(tag string, viewCount int64) bool {
+	if r.ExcludesName(tag) {
+		return true
+	}
+	return viewCount < r.minViews && !contains(r.allow, normalize(tag))
+}
+
+// ExcludedSQL returns a condition matching excluded rows, for use in raw SQL.
+// tagColumn names the tag name column; viewsColumn the view count column, or ""
+// to ignore the min views rule.
+func (r *Rules) ExcludedSQL(tagColumn, viewsColumn string) (string, []any) {
+	var conds []string
+	var args []any
+
+	if len(r.block) > 0 {
+		conds = append(conds, tagColumn+" IN ?")
+		args = append(args, r.block)
+	}
+	for _, s := range r.substrings {
+		conds = append(conds, tagColumn+" LIKE ?")
+		args = append(args, "%"+escapeLike(s)+"%")
+	}
+	for _, re := range r.regexes {
+		conds = append(conds, tagColumn+" REGEXP ?")
+		args = append(args, re.String())
+	}
+	if viewsColumn != "" && r.minViews > 0 {
+		conds = append(conds, viewsColumn+" < ?")
+		args = append(args, r.minViews)
+	}
+
+	if len(conds) == 0 {
+		return "1 = 0", nil
+	}
+	sql := "(" + strings.Join(conds, " OR ") + ")"
+	if len(r.allow) > 0 {
+		sql = "(" + tagColumn + " NOT IN ? AND " + sql + ")"
+		args = append([]any{r.allow}, args...)
+	}
+	return sql, args
+}
+
+// AllowedSQL returns the negation of ExcludedSQL
+func (r *Rules) AllowedSQL(tagColumn, viewsColumn string) (string, []any) {
+	sql, args := r.ExcludedSQL(tagColumn, viewsColumn)
+	return "NOT " + sql, args
+}
+
+// Allowed is a GORM scope keeping only rows the rules allow
+func (r *Rules) Allowed(tagColumn, viewsColumn string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		sql, args := r.AllowedSQL(tagColumn, viewsColumn)
+		return db.Where(sql, args...)
+	}
+}
+
+// Excluded is a GORM scope keeping only rows the rules exclude
+func (r *Rules) Excluded(tagColumn, viewsColumn string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		sql, args := r.ExcludedSQL(tagColumn, viewsColumn)
+		return db.Where(sql, args...)
+	}
+}
+
+func normalize(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+func contains(list []string, tag string) bool {
+	for _, item := range list {
+		if item == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeLike escapes LIKE wildcards so substrings match literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,184 @@
+package tagfilter_test
+
+import (
+	"errors"
+	"ftoolbox/database/testdb"
+	"ftoolbox/models"
+	"ftoolbox/tagfilter"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  models.TagFilterRule
+		valid bool
+	}{
+		{"substring", models.TagFilterRule{Type: models.TagFilterSubstring, Value: "+"}, true},
+		{"empty substring", models.TagFilterRule{Type: models.TagFilterSubstring, Value: "  "}, false},
+		{"regex", models.TagFilterRule{Type: models.TagFilterRegex, Value: "^cos(play)?$"}, true},
+		{"non-capturing group", models.TagFilterRule{Type: models.TagFilterRegex, Value: "^(?:a|b)$"}, true},
+		{"named group", models.TagFilterRule{Type: models.TagFilterRegex, Value: "(?P<n>x)"}, true},
+		{"invalid regex", models.TagFilterRule{Type: models.TagFilterRegex, Value: "(x"}, false},
+		{"inline flag", models.TagFilterRule{Type: models.TagFilterRegex, Value: "(?i)x"}, false},
+		{"negated inline flag", models.TagFilterRule{Type: models.TagFilterRegex, Value: "(?-i)x"}, false},
+		{"flag group", models.TagFilterRule{Type: models.TagFilterRegex, Value: "a(?s:.)b"}, false},
+		{"min views", models.TagFilterRule{Type: models.TagFilterMinViews, MinViews: 500}, true},
+		{"zero min views", models.TagFilterRule{Type: models.TagFilterMinViews}, false},
+		{"unknown type", models.TagFilterRule{Type: "prefix", Value: "x"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tagfilter.Validate(tt.rule)
+			if tt.valid && err != nil {
+				t.Fatalf("Validate() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, tagfilter.ErrInvalidRule) {
+				t.Fatalf("Validate() = %v, want ErrInvalidRule", err)
+			}
+		})
+	}
+}
+
+func TestExcludes(t *testing.T) {
+	rules := compile(t,
+		models.TagFilterRule{Type: models.TagFilterSubstring, Value: "+"},
+		models.TagFilterRule{Type: models.TagFilterRegex, Value: "^cos"},
+		models.TagFilterRule{Type: models.TagFilterBlocklist, Value: "Banned"},
+		models.TagFilterRule{Type: models.TagFilterMinViews, MinViews: 500},
+		models.TagFilterRule{Type: models.TagFilterAllowlist, Value: "tiny"},
+	)
+
+	tests := []struct {
+		tag      string
+		views    int64
+		excludes bool
+	}{
+		{"feet", 1000, false},
+		{"feet+toes", 1000, true},
+		{"Cosplay", 1000, true},
+		{"acosplay", 1000, false},
+		{"banned", 1000, true},
+		{"small", 499, true},
+		{"tiny", 1, false},
+		{" ", 1000, true},
+	}
+
+	for _, tt := range tests {
+		if got := rules.Excludes(tt.tag, tt.views); got != tt.excludes {
+			t.Errorf("Excludes(%q, %d) = %v, want %v", tt.tag, tt.views, got, tt.excludes)
+		}
+	}
+}
+
+// TestExcludedSQLMatchesExcludes checks that the database excludes exactly the tags
+// the Go rules exclude
+func TestExcludedSQLMatchesExcludes(t *testing.T) {
+	db := testdb.Open(t)
+
+	tags := []struct {
+		tag   string
+		views int64
+	}{
+		{"feet", 1000},
+		{"Feet+Toes", 1000},
+		{"cosplay", 1000},
+		{"COSPLAY_girl", 1000},
+		{"cos_play", 1000},
+		{"cosxplay", 1000},
+		{"100%real", 1000},
+		{"a&b", 1000},
+		{"Ärger", 1000},
+		{"small", 499},
+		{"tiny", 1},
+	}
+	now := time.Now()
+	for i, tag := range tags {
+		row := models.Tag{
+			ID:              string(rune('a' + i)),
+			Tag:             tag.tag,
+			ViewCount:       tag.views,
+			FanslyCreatedAt: now,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		if err := db.Create(&row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ruleSets := map[string][]models.TagFilterRule{
+		"defaults": tagfilter.DefaultRules(),
+		"regex anchored": {
+			{Type: models.TagFilterRegex, Value: "^cos"},
+		},
+		"regex case": {
+			{Type: models.TagFilterRegex, Value: "GIRL$"},
+		},
+		"regex class": {
+			{Type: models.TagFilterRegex, Value: "[0-9]+%"},
+		},
+		"regex unicode": {
+			{Type: models.TagFilterRegex, Value: "^är"},
+		},
+		"substring wildcards": {
+			{Type: models.TagFilterSubstring, Value: "_"},
+			{Type: models.TagFilterSubstring, Value: "%"},
+		},
+		"blocklist": {
+			{Type: models.TagFilterBlocklist, Value: "COSPLAY"},
+		},
+		"allowlist": append(tagfilter.DefaultRules(),
+			models.TagFilterRule{Type: models.TagFilterAllowlist, Value: "a&b"},
+			models.TagFilterRule{Type: models.TagFilterAllowlist, Value: "tiny"},
+		),
+	}
+
+	for name, ruleSet := range ruleSets {
+		t.Run(name, func(t *testing.T) {
+			for _, rule := range ruleSet {
+				rule.IsEnabled = true
+				if err := tagfilter.ValidateInDB(db, rule); err != nil {
+					t.Fatalf("ValidateInDB(%q) = %v", rule.Value, err)
+				}
+			}
+			rules := compile(t, ruleSet...)
+
+			var want []string
+			for _, tag := range tags {
+				if rules.Excludes(tag.tag, tag.views) {
+					want = append(want, tag.tag)
+				}
+			}
+
+			var got []string
+			if err := db.Model(&models.Tag{}).
+				Scopes(rules.Excluded("tag", "view_count")).
+				Order("tag").
+				Pluck("tag", &got).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			slices.Sort(want)
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Fatalf("ExcludedSQL excludes %q, Excludes excludes %q", got, want)
+			}
+		})
+	}
+}
+
+func compile(t *testing.T, rules ...models.TagFilterRule) *tagfilter.Rules {
+	t.Helper()
+	for i := range rules {
+		rules[i].IsEnabled = true
+	}
+	compiled, err := tagfilter.Compile(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return compiled
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// hashtagPattern matches #hashtags made of letters, numbers, underscores and dashes
+var hashtagPattern = regexp.MustCompile(`#([\p{L}\p{N}_-]+)`)
+
+// ExtractHashtags returns the distinct hashtags in text, without the leading '#',
+// in the order they first appear
+func ExtractHashtags(text string) []string {
+	matches := hashtagPattern.FindAllStringSubmatch(text, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{}, len(matches))
+	out := make([]string, 0, len(matches))
+	for _, m := range matches {
+		if len(m) < 2 || m[1] == "" {
+			continue
+		}
+		key := strings.ToLower(m[1])
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, m[1])
+	}
+	return out
+}
@@ -1,32 +1,39 @@
 package utils
 
 import (
+	"fmt"
+	"ftoolbox/tagfilter"
+
 	"gorm.io/gorm"
 )
 
-// CalculateTagRanks recalculates ranks for all tags
+// CalculateTagRanks recalculates ranks for all tags the tag filter rules allow
 func CalculateTagRanks(db *gorm.DB) error {
+	rules := tagfilter.Current(db)
+	allowed, allowedArgs := rules.AllowedSQL("tag", "view_count")
+	excluded, excludedArgs := rules.ExcludedSQL("tag", "view_count")
+
 	// Use raw SQL for better performance and to avoid hooks
 	// DENSE_RANK() ensures no gaps in ranking when there are ties
 	// Treat deleted tags as having 0 view count for ranking purposes
-	sql := `
+	sql := fmt.Sprintf(`
 		UPDATE tags t1
 		JOIN (
 			SELECT 
 				id,
 				DENSE_RANK() OVER (ORDER BY CASE WHEN is_deleted THEN 0 ELSE view_count END DESC, created_at ASC) as new_rank
 			FROM tags
-			WHERE tag NOT LIKE '%+%'
+			WHERE %s
 		) t2 ON t1.id = t2.id
 		SET t1.rank = t2.new_rank
-	`
+	`, allowed)
 
-	if err := db.Exec(sql).Error; err != nil {
+	if err := db.Exec(sql, allowedArgs...).Error; err != nil {
 		return err
 	}
 
-	clearSQL := `UPDATE tags SET rank = NULL WHERE tag LIKE '%+%'`
-	return db.Exec(clearSQL).Error
+	clearSQL := `UPDATE tags SET rank = NULL WHERE ` + excluded
+	return db.Exec(clearSQL, excludedArgs...).Error
 }
 
 // CalculateCreatorRanks recalculates ranks for all creators
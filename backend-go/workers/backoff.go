@@ -0,0 +1,124 @@
+package workers
+
+import (
+	"ftoolbox/fansly"
+	"ftoolbox/models"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SetCircuitBreaker makes the manager pause Fansly-dependent workers while b is open
+func (m *WorkerManager) SetCircuitBreaker(b *fansly.CircuitBreaker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.breaker = b
+}
+
+// CircuitBreakerStats returns the Fansly circuit breaker state, or nil if none is set
+func (m *WorkerManager) CircuitBreakerStats() map[string]any {
+	m.mu.RLock()
+	breaker := m.breaker
+	m.mu.RUnlock()
+
+	if breaker == nil {
+		return nil
+	}
+	return breaker.GetStats()
+}
+
+// SetRateLimitStats sets where the manager reads the Fansly rate limiter state from
+func (m *WorkerManager) SetRateLimitStats(stats func() map[string]any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.limiterStats = stats
+}
+
+// RateLimitStats returns the Fansly rate limiter state, or nil if none is set
+func (m *WorkerManager) RateLimitStats() map[string]any {
+	m.mu.RLock()
+	stats := m.limiterStats
+	m.mu.RUnlock()
+
+	if stats == nil {
+		return nil
+	}
+	return stats()
+}
+
+// breakerRetryAt returns when a paused Fansly worker may run again, or the zero
+// time if the worker can run now
+func (m *WorkerManager) breakerRetryAt(worker Worker) time.Time {
+	fanslyWorker, ok := worker.(FanslyWorker)
+	if !ok || !fanslyWorker.UsesFansly() {
+		return time.Time{}
+	}
+
+	m.mu.RLock()
+	breaker := m.breaker
+	m.mu.RUnlock()
+
+	if breaker == nil || !breaker.IsOpen() {
+		return time.Time{}
+	}
+	return breaker.RetryAt()
+}
+
+// pauseWorker skips a run while Fansly is unavailable. The skip is not a failure,
+// so it neither counts towards the backoff nor touches the worker's data.
+func (m *WorkerManager) pauseWorker(worker Worker, retryAt time.Time) time.Time {
+	name := worker.Name()
+	next := m.nextRunAfter(worker, time.Now())
+	if retryAt.After(next) {
+		next = retryAt
+	}
+
+	if err := m.db.Model(&models.Worker{}).
+		Where("name = ?", name).
+		Updates(map[string]any{
+			"status":      "paused",
+			"next_run_at": next,
+			"updated_at":  time.Now(),
+		}).Error; err != nil {
+		zap.L().Error("Failed to update worker status", zap.String("worker", name), zap.Error(err))
+	}
+
+	zap.L().Warn("Fansly circuit breaker open, pausing worker",
+		zap.String("worker", name),
+		zap.Time("next_run_at", next))
+	return next
+}
+
+// nextRunAfterRun schedules the run following one that finished at t. After
+// consecutive failures the next run is pushed out by an exponential backoff.
+func (m *WorkerManager) nextRunAfterRun(worker Worker, t time.Time, failed bool) time.Time {
+	sched, dbWorker := m.loadSchedule(worker)
+	next := sched.nextAfter(worker, t)
+	if !failed {
+		return next
+	}
+
+	failures := 1
+	if dbWorker != nil {
+		failures = dbWorker.ConsecutiveFailures + 1
+	}
+
+	backoffUntil := t.Add(m.failureBackoff(failures))
+	if backoffUntil.After(next) {
+		zap.L().Warn("Worker failing repeatedly, backing off",
+			zap.String("worker", worker.Name()),
+			zap.Int("consecutive_failures", failures),
+			zap.Time("next_run_at", backoffUntil))
+		return backoffUntil
+	}
+	return next
+}
+
+// failureBackoff doubles the base backoff for every consecutive failure after the first
+func (m *WorkerManager) failureBackoff(failures int) time.Duration {
+	backoff := m.backoffBase
+	for i := 1; i < failures && backoff < m.backoffMax; i++ {
+		backoff *= 2
+	}
+	return min(backoff, m.backoffMax)
+}
@@ -3,47 +3,86 @@ package workers
 import (
 	"context"
 	"fmt"
+	"ftoolbox/config"
 	"ftoolbox/fansly"
 	"ftoolbox/models"
+	"ftoolbox/ratelimit"
+	"ftoolbox/services"
 	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
-const creatorUpdateBatchSize = 100
+const (
+	// creatorActivityWindow is how far back creator_history is compared to measure growth
+	creatorActivityWindow = 7 * 24 * time.Hour
+	// creatorActivityMinSpan is the shortest history span trusted for a growth estimate
+	creatorActivityMinSpan = time.Hour
+	// defaultCreatorRefreshInterval applies until a creator has enough history
+	defaultCreatorRefreshInterval = 24 * time.Hour
+	// requestedCreatorMaxInterval caps the interval of creators users asked to track
+	requestedCreatorMaxInterval = 6 * time.Hour
+)
+
+// topCreatorRankCaps caps the refresh interval of creators in the top rank tiers
+var topCreatorRankCaps = []rankCap{
+	{100, 6 * time.Hour},
+	{1000, 24 * time.Hour},
+}
 
 type CreatorUpdaterWorker struct {
 	BaseWorker
-	db     *gorm.DB
-	client *fansly.Client
+	db        *gorm.DB
+	client    fansly.API
+	creators  *services.CreatorService
+	batchSize int
+	policy    refreshPolicy
+	load      refreshLoad
 }
 
-func NewCreatorUpdaterWorker(db *gorm.DB, client *fansly.Client) *CreatorUpdaterWorker {
+func NewCreatorUpdaterWorker(db *gorm.DB, cfg *config.Config, client fansly.API) *CreatorUpdaterWorker {
+	interval := time.Duration(cfg.WorkerCreatorInterval) * time.Millisecond
+
 	return &CreatorUpdaterWorker{
-		BaseWorker: NewBaseWorker("creator-updater", 10*time.Second),
+		BaseWorker: NewBaseWorker("creator-updater", interval),
 		db:         db,
 		client:     client,
+		creators:   services.NewCreatorService(db),
+		batchSize:  max(cfg.CreatorRefreshBatchSize, 1),
+		policy: refreshPolicy{
+			minInterval:      time.Duration(cfg.CreatorRefreshMinInterval) * time.Millisecond,
+			maxInterval:      time.Duration(cfg.CreatorRefreshMaxInterval) * time.Millisecond,
+			hotActivity:      0.01,   // 1% more followers per day
+			stagnantActivity: 0.0001, // 0.01% per day
+		},
 	}
 }
 
-func (w *CreatorUpdaterWorker) Run(ctx context.Context) error {
+func (w *CreatorUpdaterWorker) UsesFansly() bool {
+	return true
+}
+
+func (w *CreatorUpdaterWorker) Run(ctx context.Context) (RunResult, error) {
 	zap.L().Info("Running creator updater")
+	ctx = ratelimit.WithPriority(ctx, ratelimit.PriorityScheduled)
 
-	twentyFourHoursAgo := time.Now().Add(-24 * time.Hour)
+	// Due creators, most overdue first, so small creators are not starved by big ones
+	now := time.Now()
 
 	var creators []models.Creator
-	if err := w.db.Model(&models.Creator{}).
-		Where("last_checked_at IS NULL OR last_checked_at < ?", twentyFourHoursAgo).
+	if err := w.dueCreators(now).
+		Order("is_requested DESC").
+		Order("next_refresh_at ASC").
 		Order("followers DESC").
-		Limit(creatorUpdateBatchSize).
+		Limit(w.batchSize).
 		Find(&creators).Error; err != nil {
-		return fmt.Errorf("failed to fetch creators: %w", err)
+		return RunResult{}, fmt.Errorf("failed to fetch creators: %w", err)
 	}
 
 	if len(creators) == 0 {
 		zap.L().Debug("No creators need updating")
-		return nil
+		return RunResult{}, nil
 	}
 
 	creatorIDs := make([]string, len(creators))
@@ -51,19 +90,33 @@ func (w *CreatorUpdaterWorker) Run(ctx context.Context) error {
 		creatorIDs[i] = creator.ID
 	}
 
-	zap.L().Info("Updating creators", zap.Int("count", len(creatorIDs)))
+	loadFactor := w.loadFactor(now)
+	zap.L().Info("Updating creators", zap.Int("count", len(creatorIDs)), zap.Float64("load_factor", loadFactor))
 
 	accounts, err := w.client.GetAccountsWithContext(ctx, creatorIDs)
 	if err != nil {
-		return fmt.Errorf("failed to fetch creator accounts: %w", err)
+		return RunResult{}, fmt.Errorf("failed to fetch creator accounts: %w", err)
 	}
 
-	return w.processScheduledCreators(creators, accounts)
+	return w.processScheduledCreators(creators, accounts, loadFactor), nil
+}
+
+// dueCreators selects creators whose refresh is due at t
+func (w *CreatorUpdaterWorker) dueCreators(t time.Time) *gorm.DB {
+	return w.db.Model(&models.Creator{}).
+		Where("next_refresh_at IS NULL OR next_refresh_at <= ?", t)
 }
 
-func (w *CreatorUpdaterWorker) processScheduledCreators(creators []models.Creator, accounts []fansly.FanslyAccount) error {
+// loadFactor compares the creators overdue by more than an hour with the batches
+// the worker can fetch in an hour
+func (w *CreatorUpdaterWorker) loadFactor(now time.Time) float64 {
+	capacityPerHour := float64(w.batchSize) * float64(time.Hour) / float64(w.Interval())
+	return w.load.get(w.Name(), capacityPerHour, w.dueCreators(now.Add(-time.Hour)))
+}
+
+func (w *CreatorUpdaterWorker) processScheduledCreators(creators []models.Creator, accounts []fansly.FanslyAccount, loadFactor float64) RunResult {
 	if len(creators) == 0 {
-		return nil
+		return RunResult{}
 	}
 
 	accountsByID := make(map[string]fansly.FanslyAccount, len(accounts))
@@ -71,14 +124,15 @@ func (w *CreatorUpdaterWorker) processScheduledCreators(creators []models.Creato
 		accountsByID[account.ID] = account
 	}
 
-	updatedCreators := 0
+	stored := make(map[string]models.Creator, len(creators))
+	found := make([]fansly.FanslyAccount, 0, len(accounts))
 	missingCreators := 0
 
 	for i := range creators {
 		creator := creators[i]
 		account, exists := accountsByID[creator.ID]
 		if !exists {
-			if err := w.markCreatorCheckedAfterMiss(&creator); err != nil {
+			if err := w.markCreatorCheckedAfterMiss(&creator, loadFactor); err != nil {
 				zap.L().Error("Failed to update creator after missing account lookup",
 					zap.String("creator_id", creator.ID),
 					zap.String("username", creator.Username),
@@ -89,14 +143,15 @@ func (w *CreatorUpdaterWorker) processScheduledCreators(creators []models.Creato
 			continue
 		}
 
-		if err := w.updateCreator(&creator, &account); err != nil {
-			zap.L().Error("Failed to update creator",
-				zap.String("username", account.Username),
-				zap.Error(err))
-			continue
-		}
+		stored[creator.ID] = creator
+		found = append(found, account)
+	}
 
-		updatedCreators++
+	updatedCreators := 0
+	if err := w.storeCreators(found, stored, loadFactor); err != nil {
+		zap.L().Error("Failed to update creators", zap.Int("count", len(found)), zap.Error(err))
+	} else {
+		updatedCreators = len(found)
 	}
 
 	zap.L().Info("Creator updater run completed",
@@ -105,13 +160,14 @@ func (w *CreatorUpdaterWorker) processScheduledCreators(creators []models.Creato
 		zap.Int("updated", updatedCreators),
 		zap.Int("missing", missingCreators))
 
-	return nil
+	return RunResult{ItemsProcessed: updatedCreators + missingCreators}
 }
 
-func (w *CreatorUpdaterWorker) markCreatorCheckedAfterMiss(creator *models.Creator) error {
+func (w *CreatorUpdaterWorker) markCreatorCheckedAfterMiss(creator *models.Creator, loadFactor float64) error {
 	now := time.Now()
 	updates := map[string]any{
 		"last_checked_at": now,
+		"next_refresh_at": now.Add(w.policy.finalize(defaultCreatorRefreshInterval, loadFactor)),
 		"updated_at":      now,
 	}
 
@@ -122,6 +178,8 @@ func (w *CreatorUpdaterWorker) markCreatorCheckedAfterMiss(creator *models.Creat
 	return nil
 }
 
+// ProcessCreators stores the accounts found by discovery: new creators are created
+// and stored ones are refreshed if due, all in a few batched statements
 func (w *CreatorUpdaterWorker) ProcessCreators(accounts []fansly.FanslyAccount) error {
 	if len(accounts) == 0 {
 		zap.L().Debug("No creators to process")
@@ -130,168 +188,124 @@ func (w *CreatorUpdaterWorker) ProcessCreators(accounts []fansly.FanslyAccount)
 
 	zap.L().Info("Processing creators", zap.Int("accounts", len(accounts)))
 
-	newCreators := 0
-	updatedCreators := 0
-	twentyFourHoursAgo := time.Now().Add(-24 * time.Hour)
+	ids := make([]string, len(accounts))
+	for i, account := range accounts {
+		ids[i] = account.ID
+	}
+	stored, err := w.creators.Find(ids)
+	if err != nil {
+		return err
+	}
 
+	now := time.Now()
+	due := make([]fansly.FanslyAccount, 0, len(accounts))
+	newCreators := 0
 	for _, account := range accounts {
-		// Check if creator already exists
-		var existingCreator models.Creator
-		err := w.db.Where("id = ?", account.ID).First(&existingCreator).Error
-
-		if err == nil {
-			// Creator exists - check if it needs updating
-			if existingCreator.LastCheckedAt != nil && existingCreator.LastCheckedAt.After(twentyFourHoursAgo) {
-				// Already updated within 24 hours, skip
-				continue
-			}
-
-			// Update existing creator
-			if err := w.updateCreator(&existingCreator, &account); err != nil {
-				zap.L().Error("Failed to update creator",
-					zap.String("username", account.Username),
-					zap.Error(err))
-				continue
-			}
-			updatedCreators++
-		} else if err == gorm.ErrRecordNotFound {
-			// Create new creator
-			if err := w.createCreator(&account); err != nil {
-				zap.L().Error("Failed to create creator",
-					zap.String("username", account.Username),
-					zap.Error(err))
-				continue
-			}
-			newCreators++
-		} else {
-			// Database error
-			zap.L().Error("Failed to query creator",
-				zap.String("account_id", account.ID),
-				zap.Error(err))
+		creator, exists := stored[account.ID]
+		if exists && creator.NextRefreshAt != nil && creator.NextRefreshAt.After(now) {
 			continue
 		}
+		if !exists {
+			newCreators++
+		}
+		due = append(due, account)
+	}
+
+	if err := w.storeCreators(due, stored, w.loadFactor(now)); err != nil {
+		return err
 	}
 
 	zap.L().Info("Creator processing completed",
 		zap.Int("processed", len(accounts)),
 		zap.Int("new", newCreators),
-		zap.Int("updated", updatedCreators))
+		zap.Int("updated", len(due)-newCreators))
 
 	return nil
 }
 
-func (w *CreatorUpdaterWorker) createCreator(account *fansly.FanslyAccount) error {
-	// Start transaction
-	tx := w.db.Begin()
-
-	// Create new creator
-	displayName := account.DisplayName
-	if displayName == "" {
-		displayName = account.Username
+// storeCreators schedules and stores fresh accounts; stored holds the rows already
+// in the database, keyed by ID
+func (w *CreatorUpdaterWorker) storeCreators(accounts []fansly.FanslyAccount, stored map[string]models.Creator, loadFactor float64) error {
+	if len(accounts) == 0 {
+		return nil
 	}
 
 	now := time.Now()
-	newCreator := models.Creator{
-		ID:            account.ID,
-		Username:      account.Username,
-		DisplayName:   &displayName,
-		MediaLikes:    account.AccountMediaLikes,
-		PostLikes:     account.PostLikes,
-		Followers:     account.FollowCount,
-		ImageCount:    account.TimelineStats.ImageCount,
-		VideoCount:    account.TimelineStats.VideoCount,
-		LastCheckedAt: &now,
-	}
-
-	if err := tx.Create(&newCreator).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	// Create initial history entry
-	history := models.CreatorHistory{
-		CreatorID:  account.ID,
-		MediaLikes: account.AccountMediaLikes,
-		PostLikes:  account.PostLikes,
-		Followers:  account.FollowCount,
-		ImageCount: account.TimelineStats.ImageCount,
-		VideoCount: account.TimelineStats.VideoCount,
+	storedIDs := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		if _, exists := stored[account.ID]; exists {
+			storedIDs = append(storedIDs, account.ID)
+		}
 	}
-
-	if err := tx.Create(&history).Error; err != nil {
-		tx.Rollback()
-		return err
+	oldest, err := w.oldestHistory(storedIDs, now.Add(-creatorActivityWindow))
+	if err != nil {
+		zap.L().Warn("Failed to load creator history for scheduling", zap.Error(err))
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		return err
+	entries := make([]services.CreatorEntry, len(accounts))
+	for i := range accounts {
+		account := &accounts[i]
+		interval := w.policy.finalize(defaultCreatorRefreshInterval, loadFactor)
+		if creator, exists := stored[account.ID]; exists {
+			if creator.IsDeleted {
+				zap.L().Info("Creator exists again, clearing deleted status",
+					zap.String("username", creator.Username))
+			}
+			var history *models.CreatorHistory
+			if h, ok := oldest[account.ID]; ok {
+				history = &h
+			}
+			interval = w.refreshInterval(&creator, account, history, now, loadFactor)
+		}
+		entries[i] = services.CreatorEntry{Account: account, NextRefresh: now.Add(interval)}
 	}
 
-	zap.L().Info("Created new creator",
-		zap.String("username", account.Username),
-		zap.Int64("followers", account.FollowCount))
-
-	return nil
+	_, err = w.creators.Store(entries)
+	return err
 }
 
-func (w *CreatorUpdaterWorker) updateCreator(creator *models.Creator, account *fansly.FanslyAccount) error {
-	// If creator was previously deleted but now exists again, clear the deletion flag
-	if creator.IsDeleted {
-		creator.IsDeleted = false
-		creator.DeletedDetectedAt = nil
-		zap.L().Info("Creator exists again, clearing deleted status",
-			zap.String("username", creator.Username))
+// oldestHistory returns each creator's oldest history point since t, keyed by creator ID
+func (w *CreatorUpdaterWorker) oldestHistory(creatorIDs []string, since time.Time) (map[string]models.CreatorHistory, error) {
+	oldest := make(map[string]models.CreatorHistory, len(creatorIDs))
+	if len(creatorIDs) == 0 {
+		return oldest, nil
 	}
 
-	// Start transaction
-	tx := w.db.Begin()
-
-	// Update creator
-	now := time.Now()
-	displayName := account.DisplayName
-	if displayName == "" {
-		displayName = account.Username
+	var rows []models.CreatorHistory
+	if err := w.db.Raw(`
+		SELECT h.*
+		FROM creator_history h
+		JOIN (
+			SELECT creator_id, MIN(created_at) AS created_at
+			FROM creator_history
+			WHERE creator_id IN ? AND created_at >= ?
+			GROUP BY creator_id
+		) o ON o.creator_id = h.creator_id AND o.created_at = h.created_at
+	`, creatorIDs, since).Scan(&rows).Error; err != nil {
+		return oldest, err
 	}
-
-	creator.Username = account.Username
-	creator.DisplayName = &displayName
-	creator.MediaLikes = account.AccountMediaLikes
-	creator.PostLikes = account.PostLikes
-	creator.Followers = account.FollowCount
-	creator.ImageCount = account.TimelineStats.ImageCount
-	creator.VideoCount = account.TimelineStats.VideoCount
-	creator.LastCheckedAt = &now
-	creator.UpdatedAt = now
-
-	if err := tx.Save(creator).Error; err != nil {
-		tx.Rollback()
-		return err
+	for _, row := range rows {
+		oldest[row.CreatorID] = row
 	}
+	return oldest, nil
+}
 
-	// Create history entry
-	history := models.CreatorHistory{
-		CreatorID:  creator.ID,
-		MediaLikes: account.AccountMediaLikes,
-		PostLikes:  account.PostLikes,
-		Followers:  account.FollowCount,
-		ImageCount: account.TimelineStats.ImageCount,
-		VideoCount: account.TimelineStats.VideoCount,
-	}
+// refreshInterval picks the time until a creator's next refresh from follower growth
+// since its oldest history point within creatorActivityWindow, tightened for requested
+// and top-ranked creators
+func (w *CreatorUpdaterWorker) refreshInterval(creator *models.Creator, account *fansly.FanslyAccount, oldest *models.CreatorHistory, now time.Time, loadFactor float64) time.Duration {
+	interval := defaultCreatorRefreshInterval
 
-	if err := tx.Create(&history).Error; err != nil {
-		tx.Rollback()
-		return err
+	if oldest != nil {
+		if span := now.Sub(oldest.CreatedAt); span >= creatorActivityMinSpan {
+			interval = w.policy.intervalForActivity(relativeGrowthPerDay(oldest.Followers, account.FollowCount, span))
+		}
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		return err
+	if creator.IsRequested {
+		interval = min(interval, requestedCreatorMaxInterval)
 	}
+	interval = capByRank(interval, creator.Rank, topCreatorRankCaps)
 
-	zap.L().Debug("Updated creator",
-		zap.String("username", creator.Username),
-		zap.Int64("followers", account.FollowCount))
-
-	return nil
+	return w.policy.finalize(interval, loadFactor)
 }
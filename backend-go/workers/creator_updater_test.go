@@ -0,0 +1,68 @@
+package workers
+
+import (
+	"context"
+	"ftoolbox/database/testdb"
+	"ftoolbox/fansly"
+	"ftoolbox/models"
+	"ftoolbox/services"
+	"testing"
+	"time"
+)
+
+func TestCreatorUpdaterRun(t *testing.T) {
+	db := testdb.Open(t)
+	server, client := newFakeFansly(t)
+
+	due := time.Now().Add(-time.Minute)
+	if _, err := services.NewCreatorService(db).Store([]services.CreatorEntry{
+		{Account: &fansly.FanslyAccount{ID: "10", Username: "alice", FollowCount: 100, AccountMediaLikes: 1000}, NextRefresh: due},
+		{Account: &fansly.FanslyAccount{ID: "11", Username: "bob", FollowCount: 50}, NextRefresh: due},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	server.AddAccount(fansly.FanslyAccount{ID: "10", Username: "alice", FollowCount: 150, AccountMediaLikes: 1200})
+
+	start := time.Now()
+	result, err := NewCreatorUpdaterWorker(db, testConfig(), client).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	if result.ItemsProcessed != 2 {
+		t.Errorf("Run() = %+v, want 2 processed", result)
+	}
+	if got := server.RequestCount("/account"); got != 1 {
+		t.Errorf("account lookups = %d, want 1", got)
+	}
+
+	var alice models.Creator
+	if err := db.First(&alice, "id = ?", "10").Error; err != nil {
+		t.Fatal(err)
+	}
+	if alice.Followers != 150 || alice.MediaLikes != 1200 {
+		t.Errorf("alice = %d followers, %d media likes; want 150, 1200", alice.Followers, alice.MediaLikes)
+	}
+	if alice.NextRefreshAt == nil || !alice.NextRefreshAt.After(start) {
+		t.Errorf("alice next refresh = %v, want after the run", alice.NextRefreshAt)
+	}
+
+	var history []models.CreatorHistory
+	if err := db.Where("creator_id = ?", "10").Order("id").Find(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].Followers != 150 || history[1].MediaLikes != 1200 {
+		t.Errorf("alice history = %+v, want a second point with 150 followers", history)
+	}
+
+	// A creator missing from the response keeps its counts and is checked again later
+	var bob models.Creator
+	if err := db.First(&bob, "id = ?", "11").Error; err != nil {
+		t.Fatal(err)
+	}
+	if bob.Followers != 50 || bob.IsDeleted {
+		t.Errorf("bob = %d followers, deleted %v; want 50, false", bob.Followers, bob.IsDeleted)
+	}
+	if bob.NextRefreshAt == nil || !bob.NextRefreshAt.After(start) {
+		t.Errorf("bob next refresh = %v, want after the run", bob.NextRefreshAt)
+	}
+}
@@ -0,0 +1,222 @@
+package workers
+
+import (
+	"fmt"
+	"ftoolbox/models"
+	"ftoolbox/tagfilter"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// frontierReuseAfter keeps a source tag out of the frontier right after it was used
+	frontierReuseAfter = 3 * time.Hour
+	// frontierRecoveryTime is how long after use a source tag regains its full weight
+	frontierRecoveryTime = 7 * 24 * time.Hour
+	// frontierNoveltyPrior is the novelty assumed for tags that were never explored,
+	// so they get tried at least once
+	frontierNoveltyPrior = 1.0
+	// frontierNoveltyFloor keeps exhausted tags in rotation at a low weight
+	frontierNoveltyFloor = 0.05
+	// frontierNoveltyAlpha is the weight of the latest run in the novelty average
+	frontierNoveltyAlpha = 0.3
+	// frontierSyncInterval is how often new tags and rank changes are folded into the frontier
+	frontierSyncInterval = time.Hour
+)
+
+// frontierScoreSQL scores a frontier row f joined to its tag t: novelty per page,
+// boosted by up to 2x for top-ranked tags. Unranked and unknown tags get no boost.
+var frontierScoreSQL = fmt.Sprintf(
+	"(f.novelty + %g) * CASE WHEN t.rank IS NULL THEN 1 ELSE 1 + 1 / LOG10(t.rank + 10) END",
+	frontierNoveltyFloor)
+
+// AddDiscoverySeeds marks tags as discovery seeds, adding them to the frontier if needed.
+// Seeds stay candidates even before the tag itself has been stored.
+func AddDiscoverySeeds(db *gorm.DB, tags []string) error {
+	seeds := newDiscoverySeeds(tags)
+	if len(seeds) == 0 {
+		return nil
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tag"}},
+		DoUpdates: clause.Assignments(map[string]any{"is_seed": true, "updated_at": time.Now()}),
+	}).Create(&seeds).Error
+}
+
+// addMissingDiscoverySeeds adds seeds for tags not in the frontier yet. Existing rows
+// are left alone, so a seed removed through the admin API stays removed.
+func addMissingDiscoverySeeds(db *gorm.DB, tags []string) error {
+	seeds := newDiscoverySeeds(tags)
+	if len(seeds) == 0 {
+		return nil
+	}
+
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&seeds).Error
+}
+
+func newDiscoverySeeds(tags []string) []models.DiscoveryFrontier {
+	seeds := make([]models.DiscoveryFrontier, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		seeds = append(seeds, models.DiscoveryFrontier{
+			Tag:     tag,
+			IsSeed:  true,
+			Novelty: frontierNoveltyPrior,
+			Score:   frontierNoveltyPrior + frontierNoveltyFloor,
+		})
+	}
+	return seeds
+}
+
+// RemoveDiscoverySeed unmarks a seed and reports whether it was one. The tag stays
+// in the frontier and keeps being scored like any other tag.
+func RemoveDiscoverySeed(db *gorm.DB, tag string) (bool, error) {
+	res := db.Model(&models.DiscoveryFrontier{}).
+		Where("tag = ? AND is_seed = ?", strings.ToLower(strings.TrimSpace(tag)), true).
+		Updates(map[string]any{"is_seed": false, "updated_at": time.Now()})
+	return res.RowsAffected > 0, res.Error
+}
+
+// syncFrontier adds any tags missing from the frontier and rescores every row so rank
+// changes are picked up. It runs at most once per frontierSyncInterval; tags found by
+// discovery itself are added as they are stored. The configured seeds are added on
+// the first sync only.
+func (w *TagDiscoveryWorker) syncFrontier(now time.Time) error {
+	if now.Sub(w.frontierSyncedAt) < frontierSyncInterval {
+		return nil
+	}
+
+	if !w.seedsAdded {
+		if err := addMissingDiscoverySeeds(w.db, w.seedTags); err != nil {
+			return fmt.Errorf("failed to add seed tags: %w", err)
+		}
+		w.seedsAdded = true
+	}
+
+	allowed, allowedArgs := tagfilter.Current(w.db).AllowedSQL("t.tag", "t.view_count")
+	if err := w.db.Exec(`
+		INSERT IGNORE INTO discovery_frontier (tag, novelty, score, created_at, updated_at)
+		SELECT t.tag, ?, 0, ?, ?
+		FROM tags t
+		WHERE t.is_deleted = false
+			AND `+allowed+`
+			AND NOT EXISTS (SELECT 1 FROM discovery_frontier f WHERE f.tag = t.tag)
+	`, append([]any{frontierNoveltyPrior, now, now}, allowedArgs...)...).Error; err != nil {
+		return fmt.Errorf("failed to add tags to discovery frontier: %w", err)
+	}
+
+	if err := w.rescoreFrontier(""); err != nil {
+		return err
+	}
+
+	w.frontierSyncedAt = now
+	return nil
+}
+
+// rescoreFrontier recomputes the score of one frontier row, or of all rows if tag is empty
+func (w *TagDiscoveryWorker) rescoreFrontier(tag string) error {
+	sql := "UPDATE discovery_frontier f LEFT JOIN tags t ON t.tag = f.tag SET f.score = " + frontierScoreSQL
+	args := []any{}
+	if tag != "" {
+		sql += " WHERE f.tag = ?"
+		args = append(args, tag)
+	}
+
+	if err := w.db.Exec(sql, args...).Error; err != nil {
+		return fmt.Errorf("failed to score discovery frontier: %w", err)
+	}
+	return nil
+}
+
+// getTagForDiscovery samples a source tag from the frontier with probability
+// proportional to its score, scaled down for tags used within frontierRecoveryTime.
+// Deleted tags are skipped; seeds are eligible even if the tag isn't stored yet.
+func (w *TagDiscoveryWorker) getTagForDiscovery() (string, error) {
+	now := time.Now()
+	if err := w.syncFrontier(now); err != nil {
+		zap.L().Error("Failed to sync discovery frontier", zap.Error(err))
+	}
+
+	// Weighted sampling without replacement (Efraimidis-Spirakis): the row with the
+	// smallest -ln(u)/weight wins, which picks each row with probability weight/sum
+	rules := tagfilter.Current(w.db)
+	tagAllowed, tagAllowedArgs := rules.AllowedSQL("t.tag", "t.view_count")
+
+	var tags []string
+	err := w.db.Table("discovery_frontier f").
+		Joins("LEFT JOIN tags t ON t.tag = f.tag").
+		Where("f.last_used_at IS NULL OR f.last_used_at < ?", now.Add(-frontierReuseAfter)).
+		Where("(t.id IS NULL AND f.is_seed = ?) OR t.is_deleted = ?", true, false).
+		Scopes(rules.Allowed("f.tag", "")).
+		Where("t.id IS NULL OR "+tagAllowed, tagAllowedArgs...).
+		Order(clause.Expr{
+			SQL:  "-LN(1 - RAND()) / (GREATEST(f.score, 0.001) * LEAST(1, COALESCE(TIMESTAMPDIFF(SECOND, f.last_used_at, ?) / ?, 1)))",
+			Vars: []any{now, frontierRecoveryTime.Seconds()},
+		}).
+		Limit(1).
+		Pluck("f.tag", &tags).Error
+	if err == nil && len(tags) > 0 {
+		return tags[0], nil
+	}
+	if err != nil {
+		zap.L().Error("Failed to sample discovery frontier", zap.Error(err))
+	}
+
+	// If the frontier is empty or unavailable, use a random seed tag
+	if len(w.seedTags) > 0 {
+		return w.seedTags[rand.IntN(len(w.seedTags))], nil
+	}
+
+	return "", fmt.Errorf("no tags available for discovery")
+}
+
+// recordFrontierRun updates a source tag's frontier stats after a run. yield is the
+// number of new tags per page read, or nil if the run observed nothing.
+func (w *TagDiscoveryWorker) recordFrontierRun(tag string, pages int, result RunResult, yield *float64) {
+	now := time.Now()
+
+	// MySQL assigns left to right, so novelty has to be set while runs still holds
+	// the previous count
+	sql := "UPDATE discovery_frontier SET "
+	var args []any
+	if yield != nil {
+		sql += "novelty = CASE WHEN runs = 0 THEN ? ELSE ? * ? + (1 - ?) * novelty END, "
+		args = append(args, *yield, frontierNoveltyAlpha, *yield, frontierNoveltyAlpha)
+	}
+	sql += `runs = runs + 1, pages_read = pages_read + ?, tags_found = tags_found + ?,
+		new_tags_found = new_tags_found + ?, last_used_at = ?, updated_at = ?
+		WHERE tag = ?`
+	args = append(args, pages, result.ItemsProcessed, result.ItemsCreated, now, now, tag)
+
+	res := w.db.Exec(sql, args...)
+	if res.Error != nil {
+		zap.L().Error("Failed to update discovery frontier", zap.String("tag", tag), zap.Error(res.Error))
+		return
+	}
+	if res.RowsAffected == 0 {
+		return
+	}
+	if err := w.rescoreFrontier(tag); err != nil {
+		zap.L().Error("Failed to score discovery frontier", zap.String("tag", tag), zap.Error(err))
+	}
+}
+
+// addToFrontier makes a newly stored tag a discovery candidate
+func (w *TagDiscoveryWorker) addToFrontier(tag string) {
+	if err := w.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.DiscoveryFrontier{
+		Tag:     tag,
+		Novelty: frontierNoveltyPrior,
+		Score:   frontierNoveltyPrior + frontierNoveltyFloor,
+	}).Error; err != nil {
+		zap.L().Error("Failed to add tag to discovery frontier", zap.String("tag", tag), zap.Error(err))
+	}
+}
@@ -0,0 +1,98 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"ftoolbox/fansly"
+	"ftoolbox/models"
+	"strconv"
+	"time"
+)
+
+// loadProgress returns the stored paging progress for a source tag, or a fresh one
+func (w *TagDiscoveryWorker) loadProgress(tagID string) (*models.DiscoveryProgress, error) {
+	var progress models.DiscoveryProgress
+	res := w.db.Where("tag_id = ?", tagID).Limit(1).Find(&progress)
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to load discovery progress: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		now := time.Now()
+		progress = models.DiscoveryProgress{TagID: tagID, PassStartedAt: &now}
+	}
+	return &progress, nil
+}
+
+// fetchPage fetches the next page of a pass. Pages are walked with the "before"
+// cursor; if Fansly ignored it on an earlier page the pass falls back to offsets.
+func (w *TagDiscoveryWorker) fetchPage(ctx context.Context, progress *models.DiscoveryProgress) (*fansly.SuggestionsResponseData, error) {
+	before := "0"
+	offset := 0
+	if progress.UseOffset {
+		offset = progress.Offset
+	} else if progress.Cursor != "" {
+		before = progress.Cursor
+	}
+	return w.client.GetSuggestionsData(ctx, []string{progress.TagID}, before, "0", w.pageSize, offset)
+}
+
+// advanceProgress moves the cursor past page and reports whether the pass ended.
+// A pass ends when a page comes back short or maxPassPages have been read, after
+// which the next run starts again from the newest posts.
+func (w *TagDiscoveryWorker) advanceProgress(progress *models.DiscoveryProgress, page *fansly.SuggestionsResponseData) bool {
+	now := time.Now()
+	progress.LastPageAt = &now
+
+	var suggestions []fansly.MediaOfferSuggestion
+	if page != nil {
+		suggestions = page.MediaOfferSuggestions
+	}
+	progress.PagesRead++
+	progress.PostsRead += len(suggestions)
+
+	if len(suggestions) < w.pageSize || progress.PagesRead >= w.maxPassPages {
+		progress.Cursor = ""
+		progress.Offset = 0
+		progress.UseOffset = false
+		progress.PagesRead = 0
+		progress.PassesDone++
+		progress.PassStartedAt = &now
+		return true
+	}
+
+	if progress.UseOffset {
+		progress.Offset += len(suggestions)
+		return false
+	}
+
+	// Suggestion IDs are snowflakes, so the oldest post on the page has the smallest ID
+	oldest, ok := oldestSuggestionID(suggestions)
+	if cursor, err := strconv.ParseInt(progress.Cursor, 10, 64); ok && (progress.Cursor == "" || err == nil && oldest < cursor) {
+		progress.Cursor = strconv.FormatInt(oldest, 10)
+		return false
+	}
+
+	// The cursor didn't move the page window, so every page so far was the first
+	// one; continue this pass by offset from just after it
+	progress.Cursor = ""
+	progress.UseOffset = true
+	progress.Offset = len(suggestions)
+	return false
+}
+
+// oldestSuggestionID returns the smallest numeric suggestion ID on a page
+func oldestSuggestionID(suggestions []fansly.MediaOfferSuggestion) (int64, bool) {
+	var oldest int64
+	found := false
+	for _, s := range suggestions {
+		id, err := strconv.ParseInt(s.ID, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		if !found || id < oldest {
+			oldest = id
+			found = true
+		}
+	}
+	return oldest, found
+}
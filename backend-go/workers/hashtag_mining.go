@@ -0,0 +1,158 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ftoolbox/fansly"
+	"ftoolbox/models"
+	"ftoolbox/services"
+	"ftoolbox/tagfilter"
+	"ftoolbox/utils"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// hashtagRetryAfter is how long a hashtag that didn't resolve to a trackable tag
+// waits before it is looked up again
+const hashtagRetryAfter = 30 * 24 * time.Hour
+
+// collectHashtags records the hashtags in post content that aren't tracked tags yet
+func (w *TagDiscoveryWorker) collectHashtags(posts []fansly.FanslyPost) error {
+	rules := tagfilter.Current(w.db)
+	mentions := make(map[string]int64)
+	for _, post := range posts {
+		for _, hashtag := range utils.ExtractHashtags(post.Content) {
+			name := strings.ToLower(hashtag)
+			if !rules.ExcludesName(name) {
+				mentions[name]++
+			}
+		}
+	}
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(mentions))
+	for name := range mentions {
+		names = append(names, name)
+	}
+	var known []string
+	if err := w.db.Model(&models.Tag{}).Where("tag IN ?", names).Pluck("tag", &known).Error; err != nil {
+		return fmt.Errorf("failed to look up hashtags: %w", err)
+	}
+	for _, name := range known {
+		delete(mentions, strings.ToLower(name))
+	}
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	candidates := make([]models.HashtagCandidate, 0, len(mentions))
+	for name, count := range mentions {
+		candidates = append(candidates, models.HashtagCandidate{
+			Tag:       name,
+			Status:    models.HashtagPending,
+			Mentions:  count,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+
+	return w.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tag"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"mentions":   gorm.Expr("mentions + VALUES(mentions)"),
+			"updated_at": gorm.Expr("VALUES(updated_at)"),
+		}),
+	}).CreateInBatches(&candidates, postBatchSize).Error
+}
+
+// resolveHashtags looks up the most mentioned pending hashtags on Fansly, at most
+// hashtagBudget per run, and tracks the ones that exist and pass the filter rules.
+// It returns the number of tags created.
+func (w *TagDiscoveryWorker) resolveHashtags(ctx context.Context) (int, error) {
+	if w.hashtagBudget <= 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	var candidates []models.HashtagCandidate
+	if err := w.db.Where("status = ?", models.HashtagPending).
+		Or("status IN ? AND last_checked_at < ?", []string{models.HashtagNotFound, models.HashtagExcluded}, now.Add(-hashtagRetryAfter)).
+		Order("mentions DESC").
+		Limit(w.hashtagBudget).
+		Find(&candidates).Error; err != nil {
+		return 0, fmt.Errorf("failed to load hashtag candidates: %w", err)
+	}
+
+	rules := tagfilter.Current(w.db)
+	created := 0
+	for _, candidate := range candidates {
+		status, isNew, err := w.resolveHashtag(ctx, rules, candidate.Tag)
+		if err != nil {
+			return created, err
+		}
+		if isNew {
+			created++
+		}
+
+		checkedAt := time.Now()
+		if err := w.db.Model(&models.HashtagCandidate{}).
+			Where("tag = ?", candidate.Tag).
+			Updates(map[string]any{
+				"status":          status,
+				"last_checked_at": checkedAt,
+				"updated_at":      checkedAt,
+			}).Error; err != nil {
+			zap.L().Error("Failed to update hashtag candidate", zap.String("tag", candidate.Tag), zap.Error(err))
+		}
+	}
+
+	return created, nil
+}
+
+// resolveHashtag looks up one hashtag and stores it as a content-sourced tag if it
+// can be tracked. Errors other than the tag not existing abort the lookups.
+func (w *TagDiscoveryWorker) resolveHashtag(ctx context.Context, rules *tagfilter.Rules, name string) (string, bool, error) {
+	var count int64
+	if err := w.db.Model(&models.Tag{}).Where("tag = ?", name).Count(&count).Error; err != nil {
+		return "", false, fmt.Errorf("failed to look up hashtag: %w", err)
+	}
+	if count > 0 {
+		return models.HashtagTracked, false, nil
+	}
+
+	details, err := w.client.GetTagWithContext(ctx, name)
+	if errors.Is(err, fansly.ErrTagNotFound) {
+		return models.HashtagNotFound, false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to resolve hashtag %q: %w", name, err)
+	}
+
+	tag := details.MediaOfferSuggestionTag
+	if rules.Excludes(tag.Tag, tag.ViewCount) {
+		return models.HashtagExcluded, false, nil
+	}
+
+	newTag, created, err := w.tags.Create(tag, services.TagCreateOptions{Source: models.TagSourceContent})
+	if err != nil {
+		return "", false, err
+	}
+	if !created {
+		return models.HashtagTracked, false, nil
+	}
+	w.addToFrontier(newTag.Tag)
+
+	zap.L().Info("Discovered new tag from post content",
+		zap.String("tag", newTag.Tag),
+		zap.Int64("viewCount", newTag.ViewCount),
+		zap.Int64("postCount", newTag.PostCount))
+	return models.HashtagTracked, true, nil
+}
@@ -0,0 +1,185 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"ftoolbox/config"
+	"ftoolbox/models"
+	"ftoolbox/tagfilter"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// heatWindow is how much tag_history feeds the heat score
+	heatWindow = 7 * 24 * time.Hour
+	// heatHalfLife is the age at which growth counts half as much towards velocity
+	heatHalfLife = 24 * time.Hour
+	// heatViewPrior and heatPostPrior are added to a tag's size before normalizing,
+	// so a handful of new views on a tiny tag doesn't read as a breakout
+	heatViewPrior = 1000
+	heatPostPrior = 10
+	// heatAccelerationWeight is how much speeding up (or slowing down) adds to velocity
+	heatAccelerationWeight = 0.5
+	// heatViewWeight and heatPostWeight mix the view and post scores
+	heatViewWeight = 0.6
+	heatPostWeight = 0.4
+	// heatBatchSize bounds the tags whose history is loaded at once
+	heatBatchSize = 500
+)
+
+// HeatCalculatorWorker scores how fast each tag is growing right now. Heat is the
+// time-decayed relative growth of views and posts per day, plus how much that growth
+// has sped up compared to the whole window, in percent per day. Small tags are
+// damped by a size prior. Deleted and excluded tags have no heat.
+type HeatCalculatorWorker struct {
+	BaseWorker
+	db *gorm.DB
+}
+
+func NewHeatCalculatorWorker(db *gorm.DB, cfg *config.Config) *HeatCalculatorWorker {
+	interval := time.Duration(cfg.WorkerHeatInterval) * time.Millisecond
+
+	return &HeatCalculatorWorker{
+		BaseWorker: NewBaseWorker("heat-calculator", interval),
+		db:         db,
+	}
+}
+
+func (w *HeatCalculatorWorker) Run(ctx context.Context) (RunResult, error) {
+	zap.L().Info("Running heat calculator")
+	startTime := time.Now()
+	rules := tagfilter.Current(w.db)
+
+	var result RunResult
+	excluded, excludedArgs := rules.ExcludedSQL("tag", "view_count")
+	if err := w.db.Model(&models.Tag{}).
+		Where("heat <> 0").
+		Where("is_deleted = ? OR ("+excluded+")", append([]any{true}, excludedArgs...)...).
+		UpdateColumn("heat", 0).Error; err != nil {
+		return result, fmt.Errorf("failed to clear heat: %w", err)
+	}
+
+	lastID := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		default:
+		}
+
+		var tags []models.Tag
+		if err := w.db.Select("id", "heat").
+			Where("is_deleted = ?", false).
+			Where("id > ?", lastID).
+			Scopes(rules.Allowed("tag", "view_count")).
+			Order("id").
+			Limit(heatBatchSize).
+			Find(&tags).Error; err != nil {
+			return result, fmt.Errorf("failed to load tags: %w", err)
+		}
+		if len(tags) == 0 {
+			break
+		}
+		lastID = tags[len(tags)-1].ID
+
+		changed, err := w.updateBatch(tags, time.Now())
+		if err != nil {
+			return result, err
+		}
+		result.ItemsProcessed += len(tags)
+		result.ItemsCreated += changed
+	}
+
+	zap.L().Info("Heat calculation completed",
+		zap.Int("tags", result.ItemsProcessed),
+		zap.Int("changed", result.ItemsCreated),
+		zap.Duration("duration", time.Since(startTime)))
+
+	return result, nil
+}
+
+// updateBatch scores a batch of tags from their history and stores the heat of
+// those whose score changed, returning how many changed
+func (w *HeatCalculatorWorker) updateBatch(tags []models.Tag, now time.Time) (int, error) {
+	ids := make([]string, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+
+	var history []models.TagHistory
+	if err := w.db.Select("tag_id", "view_count", "post_count", "created_at").
+		Where("tag_id IN ? AND created_at >= ?", ids, now.Add(-heatWindow)).
+		Order("tag_id, created_at ASC").
+		Find(&history).Error; err != nil {
+		return 0, fmt.Errorf("failed to load tag history: %w", err)
+	}
+
+	historyByTag := make(map[string][]models.TagHistory, len(tags))
+	for _, point := range history {
+		historyByTag[point.TagID] = append(historyByTag[point.TagID], point)
+	}
+
+	cases := ""
+	args := make([]any, 0, len(tags)*2)
+	changedIDs := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		heat := tagHeat(historyByTag[tag.ID], now)
+		if math.Abs(heat-tag.Heat) < 1e-6 {
+			continue
+		}
+		cases += " WHEN ? THEN ?"
+		args = append(args, tag.ID, heat)
+		changedIDs = append(changedIDs, tag.ID)
+	}
+	if len(changedIDs) == 0 {
+		return 0, nil
+	}
+
+	if err := w.db.Model(&models.Tag{}).
+		Where("id IN ?", changedIDs).
+		UpdateColumn("heat", gorm.Expr("CASE id"+cases+" ELSE heat END", args...)).Error; err != nil {
+		return 0, fmt.Errorf("failed to store heat: %w", err)
+	}
+	return len(changedIDs), nil
+}
+
+// tagHeat scores a tag from its history points, oldest first
+func tagHeat(points []models.TagHistory, now time.Time) float64 {
+	views := heatScore(points, now, heatViewPrior, func(p models.TagHistory) int64 { return p.ViewCount })
+	posts := heatScore(points, now, heatPostPrior, func(p models.TagHistory) int64 { return p.PostCount })
+	return max(heatViewWeight*views+heatPostWeight*posts, 0) * 100
+}
+
+// heatScore is the decayed relative growth per day of one count plus the weighted
+// acceleration: how much the decayed growth exceeds the growth over the whole window.
+// Each step between history points is normalized by the count at its start plus prior.
+func heatScore(points []models.TagHistory, now time.Time, prior float64, count func(models.TagHistory) int64) float64 {
+	var decayedGrowth, decayedDays, growth, days float64
+	for i := 1; i < len(points); i++ {
+		prev, cur := points[i-1], points[i]
+		span := cur.CreatedAt.Sub(prev.CreatedAt).Hours() / 24
+		if span <= 0 {
+			continue
+		}
+
+		relative := float64(count(cur)-count(prev)) / (math.Max(float64(count(prev)), 0) + prior)
+		age := now.Sub(cur.CreatedAt)
+		decay := math.Exp2(-age.Hours() / heatHalfLife.Hours())
+
+		decayedGrowth += relative * decay
+		decayedDays += span * decay
+		growth += relative
+		days += span
+	}
+	if decayedDays <= 0 || days <= 0 {
+		return 0
+	}
+
+	velocity := decayedGrowth / decayedDays
+	acceleration := velocity - growth/days
+	return velocity + heatAccelerationWeight*acceleration
+}
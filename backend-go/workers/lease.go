@@ -0,0 +1,146 @@
+package workers
+
+import (
+	"context"
+	"ftoolbox/models"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Leases make sure only one replica runs a given worker at a time. Each manager
+// competes for a lease row in the workers table, renews it while it runs the
+// worker and releases it on shutdown; when a holder dies, the lease expires and
+// another replica takes over on its next renewal attempt.
+//
+// On this instance the lease belongs to one holder at a time: a worker loop, or a
+// single triggered run of a worker that isn't scheduled here. Each holder gets a new
+// generation, and only the current generation may renew or release the lease, so a
+// stopped loop winding down can't cancel, forget or free its successor's lease.
+
+// newLeaseGen makes the caller the holder of the worker's lease on this instance
+func (m *WorkerManager) newLeaseGen(name string) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.leaseGens[name]++
+	return m.leaseGens[name]
+}
+
+func (m *WorkerManager) currentLeaseGen(name string) uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.leaseGens[name]
+}
+
+// keepLease acquires and renews the worker lease until ctx is cancelled
+func (m *WorkerManager) keepLease(ctx context.Context, name string, gen uint64) {
+	defer m.wg.Done()
+
+	renewInterval := max(m.leaseDuration/3, time.Second)
+	ticker := time.NewTicker(renewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.releaseLease(name, gen)
+			return
+		case <-ticker.C:
+			m.renewLease(name, gen)
+		}
+	}
+}
+
+// renewLease takes over a free or expired lease, or extends one this instance
+// already holds. Disabled workers cannot be leased. A lost lease cancels the
+// worker's in-flight run. Holders other than gen can't renew.
+func (m *WorkerManager) renewLease(name string, gen uint64) bool {
+	if m.currentLeaseGen(name) != gen {
+		return false
+	}
+
+	// Expiry is set and checked on the database clock, so replicas with skewed
+	// clocks agree on when a lease lapses. Locally it's tracked on the local clock.
+	now := time.Now()
+	expiresAt := now.Add(m.leaseDuration)
+
+	result := m.db.Model(&models.Worker{}).
+		Where("name = ? AND is_enabled = ?", name, true).
+		Where("(lease_owner IS NULL OR lease_owner = ? OR lease_expires_at IS NULL OR lease_expires_at < NOW(3))", m.instanceID).
+		UpdateColumns(map[string]any{
+			"lease_owner":      m.instanceID,
+			"lease_expires_at": gorm.Expr("NOW(3) + INTERVAL ? MICROSECOND", m.leaseDuration.Microseconds()),
+		})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// A newer holder took over during the update and owns the lease state now
+	if m.leaseGens[name] != gen {
+		return false
+	}
+
+	held := m.leaseExpiry[name].After(now)
+
+	if result.Error != nil {
+		// Keep a held lease until it lapses locally; the next renewal may succeed
+		zap.L().Error("Failed to renew worker lease", zap.String("worker", name), zap.Error(result.Error))
+		if !held {
+			m.loseLeaseLocked(name)
+		}
+		return held
+	}
+
+	if result.RowsAffected == 0 {
+		if held {
+			zap.L().Warn("Lost worker lease", zap.String("worker", name))
+		}
+		m.loseLeaseLocked(name)
+		return false
+	}
+
+	if !held {
+		zap.L().Info("Acquired worker lease",
+			zap.String("worker", name),
+			zap.String("instance", m.instanceID))
+	}
+	m.leaseExpiry[name] = expiresAt
+	return true
+}
+
+// releaseLease gives up the lease so another replica can take over immediately.
+// Holders other than gen leave it alone.
+func (m *WorkerManager) releaseLease(name string, gen uint64) {
+	// The lock is held through the update so a new holder can't acquire the lease
+	// in between and have it cleared
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.leaseGens[name] != gen {
+		return
+	}
+	m.loseLeaseLocked(name)
+
+	if err := m.db.Model(&models.Worker{}).
+		Where("name = ? AND lease_owner = ?", name, m.instanceID).
+		UpdateColumns(map[string]any{
+			"lease_owner":      gorm.Expr("NULL"),
+			"lease_expires_at": gorm.Expr("NULL"),
+		}).Error; err != nil {
+		zap.L().Error("Failed to release worker lease", zap.String("worker", name), zap.Error(err))
+	}
+}
+
+func (m *WorkerManager) loseLeaseLocked(name string) {
+	delete(m.leaseExpiry, name)
+	if cancel, ok := m.runCancels[name]; ok {
+		cancel()
+	}
+}
+
+func (m *WorkerManager) holdsLease(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.leaseExpiry[name].After(time.Now())
+}
@@ -2,7 +2,10 @@ package workers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"ftoolbox/config"
+	"ftoolbox/fansly"
 	"ftoolbox/models"
 	"runtime/debug"
 	"sync"
@@ -12,26 +15,81 @@ import (
 	"gorm.io/gorm"
 )
 
+var (
+	ErrWorkersDisabled    = errors.New("workers are disabled on this instance")
+	ErrWorkerNotFound     = errors.New("worker not found")
+	ErrWorkerRunning      = errors.New("worker already running")
+	ErrWorkerNotRunning   = errors.New("worker not running")
+	ErrWorkerDisabled     = errors.New("worker is disabled")
+	ErrLeaseHeldElsewhere = errors.New("worker lease is held by another instance")
+	ErrWorkerTimeout      = errors.New("worker run timed out")
+	ErrInvalidSchedule    = errors.New("invalid schedule")
+)
+
+// WorkerState is a worker's database record combined with its state on this instance
+type WorkerState struct {
+	models.Worker
+	IntervalMs        int64  `json:"intervalMs"`
+	EffectiveSchedule string `json:"effectiveSchedule,omitempty"`
+	EffectiveJitterMs int64  `json:"effectiveJitterMs"`
+	Registered        bool   `json:"registered"`
+	Scheduled         bool   `json:"scheduled"`
+	Running           bool   `json:"running"`
+	HoldsLease        bool   `json:"holdsLease"`
+}
+
 type WorkerManager struct {
-	db          *gorm.DB
-	workers     map[string]Worker
-	cancelFuncs map[string]context.CancelFunc
-	running     map[string]bool
-	mu          sync.RWMutex
-	wg          sync.WaitGroup
-	enabled     bool
+	db            *gorm.DB
+	workers       map[string]Worker
+	cancelFuncs   map[string]context.CancelFunc
+	runCancels    map[string]context.CancelFunc
+	triggers      map[string]chan struct{}
+	reschedules   map[string]chan struct{}
+	running       map[string]bool
+	leaseExpiry   map[string]time.Time
+	leaseGens     map[string]uint64
+	mu            sync.RWMutex
+	wg            sync.WaitGroup
+	enabled       bool
+	instanceID    string
+	leaseDuration time.Duration
+	timeouts      map[string]time.Duration
+	schedules     map[string]string
+	jitter        map[string]time.Duration
+	backoffBase   time.Duration
+	backoffMax    time.Duration
+	breaker       *fansly.CircuitBreaker
+	limiterStats  func() map[string]any
+	lastRunPurge  time.Time
 }
 
-func NewWorkerManager(db *gorm.DB, enabled bool) *WorkerManager {
+func NewWorkerManager(db *gorm.DB, cfg *config.Config) *WorkerManager {
 	return &WorkerManager{
-		db:          db,
-		workers:     make(map[string]Worker),
-		cancelFuncs: make(map[string]context.CancelFunc),
-		running:     make(map[string]bool),
-		enabled:     enabled,
+		db:            db,
+		workers:       make(map[string]Worker),
+		cancelFuncs:   make(map[string]context.CancelFunc),
+		runCancels:    make(map[string]context.CancelFunc),
+		triggers:      make(map[string]chan struct{}),
+		reschedules:   make(map[string]chan struct{}),
+		running:       make(map[string]bool),
+		leaseExpiry:   make(map[string]time.Time),
+		leaseGens:     make(map[string]uint64),
+		enabled:       cfg.WorkerEnabled,
+		instanceID:    cfg.InstanceID,
+		leaseDuration: time.Duration(cfg.WorkerLeaseDuration) * time.Millisecond,
+		timeouts:      cfg.WorkerTimeouts,
+		schedules:     cfg.WorkerSchedules,
+		jitter:        cfg.WorkerJitter,
+		backoffBase:   time.Duration(cfg.WorkerFailureBackoff) * time.Millisecond,
+		backoffMax:    time.Duration(cfg.WorkerMaxFailureBackoff) * time.Millisecond,
 	}
 }
 
+// InstanceID returns the identifier this manager uses when holding worker leases
+func (m *WorkerManager) InstanceID() string {
+	return m.instanceID
+}
+
 // Register adds a worker to the manager
 func (m *WorkerManager) Register(worker Worker) error {
 	m.mu.Lock()
@@ -73,36 +131,32 @@ func (m *WorkerManager) Start(name string) error {
 
 	if !m.enabled {
 		zap.L().Info("Workers disabled, skipping start", zap.String("worker", name))
-		return nil
+		return ErrWorkersDisabled
 	}
 
 	worker, exists := m.workers[name]
 	if !exists {
-		return fmt.Errorf("worker %s not found", name)
+		return fmt.Errorf("%w: %s", ErrWorkerNotFound, name)
 	}
 
 	// Check if already running
 	if _, running := m.cancelFuncs[name]; running {
 		zap.L().Warn("Worker already running", zap.String("worker", name))
-		return fmt.Errorf("worker %s already running", name)
-	}
-
-	// Check if worker is enabled in database
-	var dbWorker models.Worker
-	if err := m.db.Where("name = ?", name).First(&dbWorker).Error; err != nil {
-		return fmt.Errorf("failed to fetch worker status: %w", err)
-	}
-
-	if !dbWorker.IsEnabled {
-		zap.L().Info("Worker is disabled", zap.String("worker", name))
-		return nil
+		return fmt.Errorf("%w: %s", ErrWorkerRunning, name)
 	}
 
+	// The loop starts even for disabled workers: is_enabled is enforced through
+	// the lease, so enabling the worker later takes effect without a restart
 	ctx, cancel := context.WithCancel(context.Background())
 	m.cancelFuncs[name] = cancel
+	m.triggers[name] = make(chan struct{}, 1)
+	m.reschedules[name] = make(chan struct{}, 1)
+	m.leaseGens[name]++
+	gen := m.leaseGens[name]
 
-	m.wg.Add(1)
-	go m.runWorker(ctx, worker)
+	m.wg.Add(2)
+	go m.keepLease(ctx, name, gen)
+	go m.runWorker(ctx, worker, gen)
 
 	zap.L().Info("Worker started", zap.String("worker", name))
 	return nil
@@ -115,11 +169,13 @@ func (m *WorkerManager) Stop(name string) error {
 
 	cancel, exists := m.cancelFuncs[name]
 	if !exists {
-		return fmt.Errorf("worker %s not running", name)
+		return fmt.Errorf("%w: %s", ErrWorkerNotRunning, name)
 	}
 
 	cancel()
 	delete(m.cancelFuncs, name)
+	delete(m.triggers, name)
+	delete(m.reschedules, name)
 
 	zap.L().Info("Worker stopped", zap.String("worker", name))
 	return nil
@@ -131,6 +187,8 @@ func (m *WorkerManager) StopAll() {
 	for name, cancel := range m.cancelFuncs {
 		cancel()
 		delete(m.cancelFuncs, name)
+		delete(m.triggers, name)
+		delete(m.reschedules, name)
 		zap.L().Info("Worker stopped", zap.String("worker", name))
 	}
 	m.mu.Unlock()
@@ -149,7 +207,128 @@ func (m *WorkerManager) GetStatus() ([]models.Worker, error) {
 	return workers, nil
 }
 
-func (m *WorkerManager) runWorker(ctx context.Context, worker Worker) {
+// ListWorkers returns every worker record with this instance's view of it
+func (m *WorkerManager) ListWorkers() ([]WorkerState, error) {
+	dbWorkers, err := m.GetStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	states := make([]WorkerState, 0, len(dbWorkers))
+	for _, dbWorker := range dbWorkers {
+		state := WorkerState{Worker: dbWorker}
+		if worker, ok := m.workers[dbWorker.Name]; ok {
+			sched := m.resolveSchedule(worker, &dbWorker)
+			state.Registered = true
+			state.IntervalMs = worker.Interval().Milliseconds()
+			state.EffectiveSchedule = sched.spec
+			state.EffectiveJitterMs = sched.jitter.Milliseconds()
+		}
+		_, state.Scheduled = m.cancelFuncs[dbWorker.Name]
+		state.Running = m.running[dbWorker.Name]
+		state.HoldsLease = m.leaseExpiry[dbWorker.Name].After(now)
+		states = append(states, state)
+	}
+
+	return states, nil
+}
+
+// SetEnabled persists a worker's is_enabled flag. Disabling cancels any in-flight
+// run and frees the lease, so every replica stops running it at its next renewal.
+func (m *WorkerManager) SetEnabled(name string, enabled bool) error {
+	m.mu.RLock()
+	_, exists := m.workers[name]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrWorkerNotFound, name)
+	}
+
+	updates := map[string]any{
+		"is_enabled": enabled,
+		"updated_at": time.Now(),
+	}
+	if !enabled {
+		updates["lease_owner"] = gorm.Expr("NULL")
+		updates["lease_expires_at"] = gorm.Expr("NULL")
+	}
+
+	if err := m.db.Model(&models.Worker{}).
+		Where("name = ?", name).
+		UpdateColumns(updates).Error; err != nil {
+		return fmt.Errorf("failed to update worker: %w", err)
+	}
+
+	if enabled {
+		m.renewLease(name, m.currentLeaseGen(name))
+	} else {
+		m.mu.Lock()
+		m.loseLeaseLocked(name)
+		m.mu.Unlock()
+	}
+
+	zap.L().Info("Worker enabled state changed", zap.String("worker", name), zap.Bool("enabled", enabled))
+	return nil
+}
+
+// TriggerNow runs a worker once as soon as possible, outside its schedule.
+// Workers not started on this instance run once under a temporary lease.
+func (m *WorkerManager) TriggerNow(name string) error {
+	if !m.enabled {
+		return ErrWorkersDisabled
+	}
+
+	m.mu.RLock()
+	worker, exists := m.workers[name]
+	trigger, scheduled := m.triggers[name]
+	running := m.running[name]
+	gen := m.leaseGens[name]
+	m.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrWorkerNotFound, name)
+	}
+	if running {
+		return fmt.Errorf("%w: %s", ErrWorkerRunning, name)
+	}
+
+	// A single run holds the lease itself until it finishes
+	if !scheduled {
+		gen = m.newLeaseGen(name)
+	}
+	if !m.holdsLease(name) && !m.renewLease(name, gen) {
+		var dbWorker models.Worker
+		if err := m.db.Where("name = ?", name).First(&dbWorker).Error; err == nil && !dbWorker.IsEnabled {
+			return fmt.Errorf("%w: %s", ErrWorkerDisabled, name)
+		}
+		return fmt.Errorf("%w: %s", ErrLeaseHeldElsewhere, name)
+	}
+
+	if scheduled {
+		select {
+		case trigger <- struct{}{}:
+		default:
+			// A trigger is already pending
+		}
+		zap.L().Info("Worker triggered", zap.String("worker", name))
+		return nil
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer m.releaseLease(name, gen)
+		m.executeWorker(context.Background(), worker)
+	}()
+
+	zap.L().Info("Worker triggered for a single run", zap.String("worker", name))
+	return nil
+}
+
+func (m *WorkerManager) runWorker(ctx context.Context, worker Worker, gen uint64) {
 	defer m.wg.Done()
 
 	// Panic recovery for the entire worker loop
@@ -178,45 +357,95 @@ func (m *WorkerManager) runWorker(ctx context.Context, worker Worker) {
 		}
 	}()
 
-	ticker := time.NewTicker(worker.Interval())
-	defer ticker.Stop()
+	name := worker.Name()
 
-	// Run immediately
-	m.executeWorker(ctx, worker)
+	m.mu.RLock()
+	trigger := m.triggers[name]
+	reschedule := m.reschedules[name]
+	m.mu.RUnlock()
+
+	// Take the lease first so the holder records the upcoming run
+	m.renewLease(name, gen)
+	next := m.firstRunTime(worker)
+	m.recordNextRun(name, next)
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			m.executeWorker(ctx, worker)
+		case <-reschedule:
+			next = m.firstRunTime(worker)
+			timer.Reset(time.Until(next))
+			continue
+		case <-timer.C:
+		case <-trigger:
+		}
+
+		if nextRun, ok := m.executeWorker(ctx, worker); ok {
+			next = nextRun
+		} else {
+			next = m.nextRunAfter(worker, time.Now())
 		}
+		timer.Reset(time.Until(next))
 	}
 }
 
-func (m *WorkerManager) executeWorker(ctx context.Context, worker Worker) {
+// executeWorker runs the worker once if this instance holds its lease and
+// returns the scheduled time of the following run. ok is false if the lease
+// holder or an in-flight run is responsible for scheduling instead.
+func (m *WorkerManager) executeWorker(ctx context.Context, worker Worker) (next time.Time, ok bool) {
 	name := worker.Name()
 
+	// Another instance owns this worker
+	if !m.holdsLease(name) {
+		zap.L().Debug("Worker lease held elsewhere, skipping", zap.String("worker", name))
+		return time.Time{}, false
+	}
+
 	// Check if already running
 	m.mu.RLock()
 	if m.running[name] {
 		m.mu.RUnlock()
 		zap.L().Debug("Worker already running, skipping", zap.String("worker", name))
-		return
+		return time.Time{}, false
 	}
 	m.mu.RUnlock()
 
-	// Mark as running
+	// Don't let Fansly workers fail through an outage one request at a time
+	if retryAt := m.breakerRetryAt(worker); !retryAt.IsZero() {
+		return m.pauseWorker(worker, retryAt), true
+	}
+
+	// Mark as running; the run is cancelled if the lease is lost. A run of a
+	// stopped loop or a single run may have started meanwhile.
+	runCtx, cancelRun := context.WithCancel(ctx)
 	m.mu.Lock()
+	if m.running[name] {
+		m.mu.Unlock()
+		cancelRun()
+		zap.L().Debug("Worker already running, skipping", zap.String("worker", name))
+		return time.Time{}, false
+	}
 	m.running[name] = true
+	m.runCancels[name] = cancelRun
 	m.mu.Unlock()
 
 	defer func() {
 		m.mu.Lock()
 		m.running[name] = false
+		delete(m.runCancels, name)
 		m.mu.Unlock()
+		cancelRun()
 	}()
 
+	// Any run still marked running is left over from a previous lease holder
+	if err := m.failInterruptedRuns(name, ""); err != nil {
+		zap.L().Error("Failed to reconcile interrupted runs", zap.String("worker", name), zap.Error(err))
+	}
+
 	// Update status to running
 	now := time.Now()
 	if err := m.db.Model(&models.Worker{}).
@@ -227,11 +456,17 @@ func (m *WorkerManager) executeWorker(ctx context.Context, worker Worker) {
 			"updated_at":  now,
 		}).Error; err != nil {
 		zap.L().Error("Failed to update worker status", zap.String("worker", name), zap.Error(err))
-		return
+		return time.Time{}, false
 	}
 
 	// Run the worker with panic recovery
 	startTime := time.Now()
+	run := m.startRun(name, startTime)
+	apiCalls := &fansly.RequestCounter{}
+	runCtx = fansly.WithRequestCounter(runCtx, apiCalls)
+	stopWatchdog, timeout := m.startWatchdog(name, run, cancelRun)
+
+	var result RunResult
 	var err error
 
 	// Panic recovery
@@ -249,24 +484,35 @@ func (m *WorkerManager) executeWorker(ctx context.Context, worker Worker) {
 			}
 		}()
 
-		err = worker.Run(ctx)
+		result, err = worker.Run(runCtx)
 	}()
 
+	if stopWatchdog() {
+		if err != nil {
+			err = fmt.Errorf("%w after %s: %w", ErrWorkerTimeout, timeout, err)
+		} else {
+			err = fmt.Errorf("%w after %s", ErrWorkerTimeout, timeout)
+		}
+	}
+
 	duration := time.Since(startTime)
+	m.finishRun(run, result, apiCalls.Count(), err)
 
 	// Update worker status based on result
+	next = m.nextRunAfterRun(worker, time.Now(), err != nil)
 	status := "idle"
 	updates := map[string]any{
 		"status":      status,
 		"run_count":   gorm.Expr("run_count + 1"),
 		"updated_at":  time.Now(),
-		"next_run_at": time.Now().Add(worker.Interval()),
+		"next_run_at": next,
 	}
 
 	if err != nil {
 		status = "failed"
 		updates["status"] = status
 		updates["failure_count"] = gorm.Expr("failure_count + 1")
+		updates["consecutive_failures"] = gorm.Expr("consecutive_failures + 1")
 		updates["last_error"] = err.Error()
 
 		zap.L().Error("Worker failed",
@@ -275,11 +521,17 @@ func (m *WorkerManager) executeWorker(ctx context.Context, worker Worker) {
 			zap.Duration("duration", duration))
 	} else {
 		updates["success_count"] = gorm.Expr("success_count + 1")
+		updates["consecutive_failures"] = 0
 		updates["last_error"] = nil
 
 		zap.L().Info("Worker completed",
 			zap.String("worker", name),
-			zap.Duration("duration", duration))
+			zap.Duration("duration", duration),
+			zap.Int("processed", result.ItemsProcessed),
+			zap.Int("created", result.ItemsCreated),
+			zap.Int("deleted", result.ItemsDeleted),
+			zap.Int("failed", result.ItemsFailed),
+			zap.Int64("api_calls", apiCalls.Count()))
 	}
 
 	if err := m.db.Model(&models.Worker{}).
@@ -287,4 +539,6 @@ func (m *WorkerManager) executeWorker(ctx context.Context, worker Worker) {
 		Updates(updates).Error; err != nil {
 		zap.L().Error("Failed to update worker status", zap.String("worker", name), zap.Error(err))
 	}
+
+	return next, true
 }
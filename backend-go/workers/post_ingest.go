@@ -0,0 +1,247 @@
+package workers
+
+import (
+	"fmt"
+	"ftoolbox/fansly"
+	"ftoolbox/models"
+	"ftoolbox/tagfilter"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// postBatchSize bounds the rows per multi-row insert when storing posts
+const postBatchSize = 100
+
+// storePosts upserts the posts on a suggestions page, links them to the tags of
+// their suggestion and snapshots their engagement when it changed. It returns the
+// number of posts seen for the first time.
+func (w *TagDiscoveryWorker) storePosts(suggestions *fansly.SuggestionsResponseData) (int, error) {
+	if suggestions == nil || suggestions.AggregationData == nil || len(suggestions.AggregationData.Posts) == 0 {
+		return 0, nil
+	}
+	posts := suggestions.AggregationData.Posts
+
+	ids := make([]string, 0, len(posts))
+	for _, post := range posts {
+		if post.ID != "" {
+			ids = append(ids, post.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var stored []models.Post
+	if err := w.db.Select("id", "like_count", "reply_count", "media_like_count", "total_tip_amount").
+		Where("id IN ?", ids).
+		Find(&stored).Error; err != nil {
+		return 0, fmt.Errorf("failed to load posts: %w", err)
+	}
+	existing := make(map[string]models.Post, len(stored))
+	for _, post := range stored {
+		existing[post.ID] = post
+	}
+
+	now := time.Now()
+	rows := make([]models.Post, 0, len(posts))
+	history := make([]models.PostHistory, 0, len(posts))
+	created := 0
+	for _, p := range posts {
+		if p.ID == "" {
+			continue
+		}
+		row := models.Post{
+			ID:                  p.ID,
+			AccountID:           p.AccountID,
+			Content:             p.Content,
+			LikeCount:           int64(p.LikeCount),
+			ReplyCount:          int64(p.ReplyCount),
+			MediaLikeCount:      int64(p.MediaLikeCount),
+			TotalTipAmount:      int64(p.TotalTipAmount),
+			TipAmount:           int64(p.TipAmount),
+			AttachmentTipAmount: int64(p.AttachmentTipAmount),
+			AttachmentCount:     len(p.Attachments),
+			FanslyCreatedAt:     postTimestamp(p.CreatedAt),
+			LastSeenAt:          now,
+		}
+		if p.ExpiresAt != nil && *p.ExpiresAt > 0 {
+			expiresAt := postTimestamp(*p.ExpiresAt)
+			row.ExpiresAt = &expiresAt
+		}
+		rows = append(rows, row)
+
+		prev, ok := existing[p.ID]
+		if !ok {
+			created++
+		}
+		if !ok || prev.LikeCount != row.LikeCount || prev.ReplyCount != row.ReplyCount ||
+			prev.MediaLikeCount != row.MediaLikeCount || prev.TotalTipAmount != row.TotalTipAmount {
+			history = append(history, models.PostHistory{
+				PostID:         row.ID,
+				LikeCount:      row.LikeCount,
+				ReplyCount:     row.ReplyCount,
+				MediaLikeCount: row.MediaLikeCount,
+				TotalTipAmount: row.TotalTipAmount,
+				CreatedAt:      now,
+				UpdatedAt:      now,
+			})
+		}
+	}
+
+	if err := w.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"content", "like_count", "reply_count", "media_like_count", "total_tip_amount",
+			"tip_amount", "attachment_tip_amount", "attachment_count", "expires_at",
+			"last_seen_at", "updated_at",
+		}),
+	}).CreateInBatches(&rows, postBatchSize).Error; err != nil {
+		return 0, fmt.Errorf("failed to upsert posts: %w", err)
+	}
+
+	if len(history) > 0 {
+		if err := w.db.CreateInBatches(&history, postBatchSize).Error; err != nil {
+			return created, fmt.Errorf("failed to create post history: %w", err)
+		}
+	}
+
+	links, err := w.newPostTagLinks(postTagLinks(suggestions.MediaOfferSuggestions, postIDSet(rows), tagfilter.Current(w.db)), ids)
+	if err != nil {
+		return created, err
+	}
+	if len(links) == 0 {
+		return created, nil
+	}
+
+	if err := w.db.Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(&links, postBatchSize).Error; err != nil {
+		return created, fmt.Errorf("failed to link posts to tags: %w", err)
+	}
+
+	if err := w.recordCreatorTags(links, rows, now); err != nil {
+		return created, err
+	}
+
+	return created, nil
+}
+
+// newPostTagLinks drops the links that are already stored for postIDs
+func (w *TagDiscoveryWorker) newPostTagLinks(links []models.PostTag, postIDs []string) ([]models.PostTag, error) {
+	if len(links) == 0 {
+		return nil, nil
+	}
+
+	var stored []models.PostTag
+	if err := w.db.Select("post_id", "tag_id").Where("post_id IN ?", postIDs).Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to load post tags: %w", err)
+	}
+	known := make(map[[2]string]struct{}, len(stored))
+	for _, link := range stored {
+		known[[2]string{link.PostID, link.TagID}] = struct{}{}
+	}
+
+	fresh := links[:0]
+	for _, link := range links {
+		if _, ok := known[[2]string{link.PostID, link.TagID}]; !ok {
+			fresh = append(fresh, link)
+		}
+	}
+	return fresh, nil
+}
+
+// recordCreatorTags counts each newly linked post once towards its creator's daily
+// usage of the tag, bucketed by the day the post was made
+func (w *TagDiscoveryWorker) recordCreatorTags(links []models.PostTag, posts []models.Post, now time.Time) error {
+	postsByID := make(map[string]models.Post, len(posts))
+	for _, post := range posts {
+		postsByID[post.ID] = post
+	}
+
+	type key struct {
+		creator, tag string
+		bucket       time.Time
+	}
+	counts := make(map[key]int64)
+	for _, link := range links {
+		post, ok := postsByID[link.PostID]
+		if !ok || post.AccountID == "" {
+			continue
+		}
+		counts[key{post.AccountID, link.TagID, post.FanslyCreatedAt.UTC().Truncate(24 * time.Hour)}]++
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	rows := make([]models.CreatorTagDaily, 0, len(counts))
+	for k, count := range counts {
+		rows = append(rows, models.CreatorTagDaily{
+			CreatorID:  k.creator,
+			TagID:      k.tag,
+			BucketDate: k.bucket,
+			PostCount:  count,
+			LastSeenAt: now,
+		})
+	}
+
+	if err := w.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "creator_id"}, {Name: "tag_id"}, {Name: "bucket_date"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"post_count":   gorm.Expr("post_count + VALUES(post_count)"),
+			"last_seen_at": gorm.Expr("VALUES(last_seen_at)"),
+		}),
+	}).CreateInBatches(&rows, postBatchSize).Error; err != nil {
+		return fmt.Errorf("failed to record creator tags: %w", err)
+	}
+	return nil
+}
+
+// postTagLinks maps each suggestion to its post and links the post to the
+// suggestion's allowed tags. Suggestions reference their post by correlation ID.
+func postTagLinks(suggestions []fansly.MediaOfferSuggestion, postIDs map[string]struct{}, rules *tagfilter.Rules) []models.PostTag {
+	var links []models.PostTag
+	seen := make(map[models.PostTag]struct{})
+	for _, s := range suggestions {
+		postID := s.CorrelationID
+		if postID == "" {
+			postID = s.ID
+		}
+		if _, ok := postIDs[postID]; !ok {
+			continue
+		}
+
+		for _, tag := range s.PostTags {
+			tagID := strings.TrimSpace(tag.ID)
+			if tagID == "" || rules.Excludes(tag.Tag, tag.ViewCount) {
+				continue
+			}
+			link := models.PostTag{PostID: postID, TagID: tagID}
+			if _, dup := seen[link]; dup {
+				continue
+			}
+			seen[link] = struct{}{}
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+func postIDSet(posts []models.Post) map[string]struct{} {
+	ids := make(map[string]struct{}, len(posts))
+	for _, post := range posts {
+		ids[post.ID] = struct{}{}
+	}
+	return ids
+}
+
+// postTimestamp converts a post timestamp, which Fansly sends in seconds, or in
+// milliseconds like tag timestamps
+func postTimestamp(ts int64) time.Time {
+	if ts >= 1e12 {
+		return time.UnixMilli(ts)
+	}
+	return time.Unix(ts, 0)
+}
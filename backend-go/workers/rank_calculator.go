@@ -2,6 +2,8 @@ package workers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"ftoolbox/config"
 	"ftoolbox/utils"
 	"time"
@@ -10,10 +12,12 @@ import (
 	"gorm.io/gorm"
 )
 
+// rankHistoryRetention is how long daily ranks are kept
+const rankHistoryRetention = 400 * 24 * time.Hour
+
 type RankCalculatorWorker struct {
 	BaseWorker
-	db       *gorm.DB
-	interval time.Duration
+	db *gorm.DB
 }
 
 func NewRankCalculatorWorker(db *gorm.DB, cfg *config.Config) *RankCalculatorWorker {
@@ -22,43 +26,79 @@ func NewRankCalculatorWorker(db *gorm.DB, cfg *config.Config) *RankCalculatorWor
 	return &RankCalculatorWorker{
 		BaseWorker: NewBaseWorker("rank-calculator", interval),
 		db:         db,
-		interval:   interval,
 	}
 }
 
-func (w *RankCalculatorWorker) Run(ctx context.Context) error {
-	ticker := time.NewTicker(w.interval)
-	defer ticker.Stop()
-
-	// Calculate ranks immediately on startup
-	w.calculateRanks()
-
-	for {
-		select {
-		case <-ctx.Done():
-			zap.L().Info("Rank calculator worker stopping")
-			return ctx.Err()
-		case <-ticker.C:
-			w.calculateRanks()
-		}
+// Run recalculates ranks once; the manager schedules it every interval
+func (w *RankCalculatorWorker) Run(ctx context.Context) (RunResult, error) {
+	select {
+	case <-ctx.Done():
+		return RunResult{}, ctx.Err()
+	default:
 	}
+
+	return RunResult{}, w.calculateRanks()
 }
 
-func (w *RankCalculatorWorker) calculateRanks() {
+func (w *RankCalculatorWorker) calculateRanks() error {
 	startTime := time.Now()
 	zap.L().Info("Starting rank calculation")
 
+	var errs []error
+	day := startTime.UTC().Truncate(24 * time.Hour)
+
 	// Calculate tag ranks
 	if err := utils.CalculateTagRanks(w.db); err != nil {
 		zap.L().Error("Failed to calculate tag ranks", zap.Error(err))
+		errs = append(errs, err)
+	} else if err := w.recordDailyRanks("tag_ranks_daily", "tag_id", "tags", day); err != nil {
+		zap.L().Error("Failed to record daily tag ranks", zap.Error(err))
+		errs = append(errs, err)
 	}
 
 	// Calculate creator ranks
 	if err := utils.CalculateCreatorRanks(w.db); err != nil {
 		zap.L().Error("Failed to calculate creator ranks", zap.Error(err))
+		errs = append(errs, err)
+	} else if err := w.recordDailyRanks("creator_ranks_daily", "creator_id", "creators", day); err != nil {
+		zap.L().Error("Failed to record daily creator ranks", zap.Error(err))
+		errs = append(errs, err)
 	}
 
 	duration := time.Since(startTime)
 	zap.L().Info("Rank calculation completed",
 		zap.Duration("duration", duration))
+
+	return errors.Join(errs...)
+}
+
+// recordDailyRanks stores the current ranks of the source table as the day's ranks,
+// unless an earlier run already did, and then drops ranks past rankHistoryRetention
+func (w *RankCalculatorWorker) recordDailyRanks(table, idColumn, source string, day time.Time) error {
+	var recorded []int
+	if err := w.db.Raw("SELECT 1 FROM "+table+" WHERE bucket_date = ? LIMIT 1", day).
+		Scan(&recorded).Error; err != nil {
+		return fmt.Errorf("failed to check %s: %w", table, err)
+	}
+	if len(recorded) > 0 {
+		return nil
+	}
+
+	if err := w.db.Exec("INSERT IGNORE INTO "+table+" ("+idColumn+", bucket_date, rank) "+
+		"SELECT id, ?, rank FROM "+source+" WHERE rank IS NOT NULL", day).Error; err != nil {
+		return fmt.Errorf("failed to record %s: %w", table, err)
+	}
+
+	cutoff := day.Add(-rankHistoryRetention)
+	tx := w.db.Exec("DELETE FROM "+table+" WHERE bucket_date < ?", cutoff)
+	if tx.Error != nil {
+		return fmt.Errorf("failed to purge %s: %w", table, tx.Error)
+	}
+	if tx.RowsAffected > 0 {
+		zap.L().Info("Purged old daily ranks",
+			zap.String("table", table),
+			zap.Int64("rows", tx.RowsAffected),
+			zap.Time("cutoff", cutoff))
+	}
+	return nil
 }
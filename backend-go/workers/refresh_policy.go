@@ -0,0 +1,117 @@
+package workers
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// refreshJitter spreads due times by up to ±10% so refreshes don't bunch up
+	refreshJitter = 0.1
+	// refreshLoadTTL limits how often the scheduled refresh demand is recomputed
+	refreshLoadTTL = 10 * time.Minute
+)
+
+// refreshPolicy turns how fast a record changes into how long until its next refresh.
+// Activity is relative growth per day; records at or above hotActivity refresh every
+// minInterval, records at or below stagnantActivity every maxInterval, and anything
+// in between on a log scale.
+type refreshPolicy struct {
+	minInterval      time.Duration
+	maxInterval      time.Duration
+	hotActivity      float64
+	stagnantActivity float64
+}
+
+func (p refreshPolicy) intervalForActivity(activity float64) time.Duration {
+	switch {
+	case activity >= p.hotActivity:
+		return p.minInterval
+	case activity <= p.stagnantActivity:
+		return p.maxInterval
+	}
+
+	// 0 at the hot end, 1 at the stagnant end
+	position := math.Log(p.hotActivity/activity) / math.Log(p.hotActivity/p.stagnantActivity)
+	ratio := float64(p.maxInterval) / float64(p.minInterval)
+	return time.Duration(float64(p.minInterval) * math.Pow(ratio, position))
+}
+
+// finalize stretches an interval by the load factor, jitters it and clamps it to the policy bounds
+func (p refreshPolicy) finalize(interval time.Duration, loadFactor float64) time.Duration {
+	stretched := float64(interval) * max(loadFactor, 1)
+	jittered := stretched * (1 - refreshJitter + 2*refreshJitter*rand.Float64())
+	return min(max(time.Duration(jittered), p.minInterval), p.maxInterval)
+}
+
+// rankCap caps the refresh interval of records ranked at or above rank
+type rankCap struct {
+	rank        int
+	maxInterval time.Duration
+}
+
+// capByRank applies the first cap whose rank covers the record's rank; caps must be sorted by rank
+func capByRank(interval time.Duration, rank *int, caps []rankCap) time.Duration {
+	if rank == nil {
+		return interval
+	}
+	for _, rankCap := range caps {
+		if *rank <= rankCap.rank {
+			return min(interval, rankCap.maxInterval)
+		}
+	}
+	return interval
+}
+
+// relativeGrowthPerDay returns |current - previous| / current, per day elapsed
+func relativeGrowthPerDay(previous, current int64, elapsed time.Duration) float64 {
+	days := elapsed.Hours() / 24
+	if days <= 0 {
+		return 0
+	}
+	return math.Abs(float64(current-previous)) / float64(max(current, 1)) / days
+}
+
+// refreshLoad tracks whether the worker keeps up with the refreshes that fall due.
+// The load factor is the overdue backlog over what the worker can fetch in an hour;
+// while it exceeds 1, new intervals are stretched by it so the schedule fits the
+// worker's share of the Fansly budget, and they relax again as the backlog drains.
+type refreshLoad struct {
+	mu         sync.Mutex
+	factor     float64
+	computedAt time.Time
+}
+
+// get returns the cached load factor, recounting the backlog with overdue when stale
+func (l *refreshLoad) get(name string, capacityPerHour float64, overdue *gorm.DB) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if time.Since(l.computedAt) < refreshLoadTTL && l.factor > 0 {
+		return l.factor
+	}
+
+	var backlog int64
+	if err := overdue.Count(&backlog).Error; err != nil {
+		zap.L().Error("Failed to count refresh backlog", zap.String("worker", name), zap.Error(err))
+		return max(l.factor, 1)
+	}
+
+	l.factor = 1
+	if capacityPerHour > 0 && float64(backlog) > capacityPerHour {
+		l.factor = float64(backlog) / capacityPerHour
+	}
+	l.computedAt = time.Now()
+
+	zap.L().Info("Computed refresh load",
+		zap.String("worker", name),
+		zap.Int64("backlog", backlog),
+		zap.Float64("capacity_per_hour", capacityPerHour),
+		zap.Float64("load_factor", l.factor))
+	return l.factor
+}
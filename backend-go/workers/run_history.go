@@ -0,0 +1,116 @@
+package workers
+
+import (
+	"errors"
+	"ftoolbox/models"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// workerRunRetention is how long worker_runs rows are kept
+	workerRunRetention = 30 * 24 * time.Hour
+	// workerRunPurgeInterval limits how often old runs are purged
+	workerRunPurgeInterval = time.Hour
+)
+
+// startRun records the beginning of a worker run and returns its row,
+// or nil if the row could not be written
+func (m *WorkerManager) startRun(name string, startedAt time.Time) *models.WorkerRun {
+	run := &models.WorkerRun{
+		WorkerName: name,
+		InstanceID: m.instanceID,
+		Status:     "running",
+		StartedAt:  startedAt,
+	}
+
+	if err := m.db.Create(run).Error; err != nil {
+		zap.L().Error("Failed to record worker run start", zap.String("worker", name), zap.Error(err))
+		return nil
+	}
+
+	return run
+}
+
+// finishRun stores the outcome and counters of a worker run
+func (m *WorkerManager) finishRun(run *models.WorkerRun, result RunResult, apiCalls int64, runErr error) {
+	if run == nil {
+		return
+	}
+
+	finishedAt := time.Now()
+	updates := map[string]any{
+		"status":          "success",
+		"finished_at":     finishedAt,
+		"duration_ms":     finishedAt.Sub(run.StartedAt).Milliseconds(),
+		"items_processed": result.ItemsProcessed,
+		"items_created":   result.ItemsCreated,
+		"items_deleted":   result.ItemsDeleted,
+		"items_failed":    result.ItemsFailed,
+		"api_calls":       apiCalls,
+		"updated_at":      finishedAt,
+	}
+	if runErr != nil {
+		updates["status"] = "failed"
+		if errors.Is(runErr, ErrWorkerTimeout) {
+			updates["status"] = "timeout"
+		}
+		updates["error"] = runErr.Error()
+	}
+
+	if err := m.db.Model(&models.WorkerRun{}).
+		Where("id = ?", run.ID).
+		Updates(updates).Error; err != nil {
+		zap.L().Error("Failed to record worker run result", zap.String("worker", run.WorkerName), zap.Error(err))
+	}
+
+	m.purgeOldRuns()
+}
+
+// purgeOldRuns deletes run history past the retention window, at most once per interval
+func (m *WorkerManager) purgeOldRuns() {
+	m.mu.Lock()
+	if time.Since(m.lastRunPurge) < workerRunPurgeInterval {
+		m.mu.Unlock()
+		return
+	}
+	m.lastRunPurge = time.Now()
+	m.mu.Unlock()
+
+	cutoff := time.Now().Add(-workerRunRetention)
+	tx := m.db.Where("started_at < ?", cutoff).Delete(&models.WorkerRun{})
+	if tx.Error != nil {
+		zap.L().Error("Failed to purge old worker runs", zap.Error(tx.Error))
+		return
+	}
+	if tx.RowsAffected > 0 {
+		zap.L().Info("Purged old worker runs", zap.Int64("rows", tx.RowsAffected), zap.Time("cutoff", cutoff))
+	}
+}
+
+// GetRuns returns a page of a worker's run history, newest first, and the total count
+func (m *WorkerManager) GetRuns(name string, limit, offset int) ([]models.WorkerRun, int64, error) {
+	query := m.db.Model(&models.WorkerRun{}).Where("worker_name = ?", name)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var runs []models.WorkerRun
+	if err := query.Order("started_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&runs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	for i := range runs {
+		if runs[i].DurationMs > 0 {
+			runs[i].ItemsPerMinute = float64(runs[i].ItemsProcessed) / (float64(runs[i].DurationMs) / float64(time.Minute.Milliseconds()))
+		}
+	}
+
+	return runs, total, nil
+}
@@ -0,0 +1,187 @@
+package workers
+
+import (
+	"fmt"
+	"ftoolbox/models"
+	"ftoolbox/schedule"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Schedules come from, in order of precedence, the workers row (set through the
+// admin API), WORKER_SCHEDULES / WORKER_JITTER, and the worker's own interval.
+// They are resolved again before every run, so changes reach every replica.
+
+// workerSchedule is a worker's effective schedule
+type workerSchedule struct {
+	schedule.Schedule
+	spec   string
+	jitter time.Duration
+}
+
+// resolveSchedule picks the effective schedule for a worker given its database row,
+// which may be nil
+func (m *WorkerManager) resolveSchedule(worker Worker, dbWorker *models.Worker) workerSchedule {
+	name := worker.Name()
+	spec := m.schedules[name]
+	jitter := m.jitter[name]
+
+	if dbWorker != nil {
+		if dbWorker.Schedule != nil && *dbWorker.Schedule != "" {
+			spec = *dbWorker.Schedule
+		}
+		if dbWorker.JitterMs != nil {
+			jitter = time.Duration(*dbWorker.JitterMs) * time.Millisecond
+		}
+	}
+
+	if spec != "" {
+		parsed, err := schedule.Parse(spec)
+		if err == nil {
+			return workerSchedule{Schedule: schedule.WithJitter(parsed, jitter), spec: spec, jitter: jitter}
+		}
+		zap.L().Warn("Invalid worker schedule, falling back to interval",
+			zap.String("worker", name),
+			zap.String("schedule", spec),
+			zap.Error(err))
+	}
+
+	interval := schedule.Interval{Every: worker.Interval()}
+	return workerSchedule{
+		Schedule: schedule.WithJitter(interval, jitter),
+		spec:     "@every " + worker.Interval().String(),
+		jitter:   jitter,
+	}
+}
+
+// loadSchedule reads the worker row and resolves its schedule. The row is also
+// returned and is nil if it could not be read.
+func (m *WorkerManager) loadSchedule(worker Worker) (workerSchedule, *models.Worker) {
+	var dbWorker models.Worker
+	if err := m.db.Where("name = ?", worker.Name()).First(&dbWorker).Error; err != nil {
+		zap.L().Error("Failed to load worker schedule", zap.String("worker", worker.Name()), zap.Error(err))
+		return m.resolveSchedule(worker, nil), nil
+	}
+	return m.resolveSchedule(worker, &dbWorker), &dbWorker
+}
+
+// nextRunAfter returns the worker's next run time after t
+func (m *WorkerManager) nextRunAfter(worker Worker, t time.Time) time.Time {
+	sched, _ := m.loadSchedule(worker)
+	return sched.nextAfter(worker, t)
+}
+
+// nextAfter returns the schedule's next time after t, falling back to the
+// worker's interval for schedules that never fire
+func (s workerSchedule) nextAfter(worker Worker, t time.Time) time.Time {
+	if next := s.Next(t); !next.IsZero() {
+		return next
+	}
+
+	zap.L().Warn("Worker schedule never fires, falling back to interval",
+		zap.String("worker", worker.Name()),
+		zap.String("schedule", s.spec))
+	return t.Add(worker.Interval())
+}
+
+// firstRunTime decides when a worker loop runs first. A next_run_at still in the
+// future is kept, so restarts do not fire every worker at once; a missed run or an
+// interval worker that never ran starts within its jitter window.
+func (m *WorkerManager) firstRunTime(worker Worker) time.Time {
+	now := time.Now()
+	sched, dbWorker := m.loadSchedule(worker)
+
+	next := sched.Next(now)
+	if next.IsZero() {
+		next = now.Add(worker.Interval())
+	}
+
+	var stored *time.Time
+	if dbWorker != nil {
+		stored = dbWorker.NextRunAt
+	}
+
+	switch {
+	case stored != nil && stored.After(now):
+		if stored.Before(next) {
+			return *stored
+		}
+		return next
+	case stored != nil || !schedule.IsCron(sched.Schedule):
+		return now.Add(schedule.Jitter(sched.jitter))
+	default:
+		return next
+	}
+}
+
+// recordNextRun stores next_run_at if this instance holds the worker's lease
+func (m *WorkerManager) recordNextRun(name string, next time.Time) {
+	if err := m.db.Model(&models.Worker{}).
+		Where("name = ? AND lease_owner = ?", name, m.instanceID).
+		UpdateColumn("next_run_at", next).Error; err != nil {
+		zap.L().Error("Failed to record next worker run", zap.String("worker", name), zap.Error(err))
+	}
+}
+
+// SetSchedule stores a worker's schedule override and jitter. An empty spec clears
+// the schedule override and a negative jitter clears the jitter override; nil leaves
+// the value unchanged. next_run_at is recomputed and the local loop rescheduled.
+func (m *WorkerManager) SetSchedule(name string, spec *string, jitterMs *int64) error {
+	m.mu.RLock()
+	worker, exists := m.workers[name]
+	reschedule := m.reschedules[name]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrWorkerNotFound, name)
+	}
+
+	updates := map[string]any{
+		"updated_at": time.Now(),
+	}
+	if spec != nil {
+		if *spec == "" {
+			updates["schedule"] = gorm.Expr("NULL")
+		} else {
+			parsed, err := schedule.Parse(*spec)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+			}
+			if parsed.Next(time.Now()).IsZero() {
+				return fmt.Errorf("%w: %q never fires", ErrInvalidSchedule, *spec)
+			}
+			updates["schedule"] = *spec
+		}
+	}
+	if jitterMs != nil {
+		if *jitterMs < 0 {
+			updates["jitter_ms"] = gorm.Expr("NULL")
+		} else {
+			updates["jitter_ms"] = *jitterMs
+		}
+	}
+
+	if err := m.db.Model(&models.Worker{}).
+		Where("name = ?", name).
+		UpdateColumns(updates).Error; err != nil {
+		return fmt.Errorf("failed to update worker schedule: %w", err)
+	}
+
+	next := m.nextRunAfter(worker, time.Now())
+	if err := m.db.Model(&models.Worker{}).
+		Where("name = ?", name).
+		UpdateColumn("next_run_at", next).Error; err != nil {
+		return fmt.Errorf("failed to update next run: %w", err)
+	}
+
+	if reschedule != nil {
+		select {
+		case reschedule <- struct{}{}:
+		default:
+		}
+	}
+
+	zap.L().Info("Worker schedule changed", zap.String("worker", name), zap.Time("next_run_at", next))
+	return nil
+}
@@ -0,0 +1,78 @@
+package workers
+
+import (
+	"context"
+	"ftoolbox/models"
+	"ftoolbox/schedule"
+	"testing"
+	"time"
+)
+
+// stubWorker is a worker whose runs do nothing
+type stubWorker struct {
+	BaseWorker
+}
+
+func (stubWorker) Run(ctx context.Context) (RunResult, error) {
+	return RunResult{}, nil
+}
+
+func TestResolveSchedule(t *testing.T) {
+	worker := stubWorker{NewBaseWorker("tag-updater", 10*time.Minute)}
+	m := &WorkerManager{
+		schedules: map[string]string{"tag-updater": "0 * * * *"},
+		jitter:    map[string]time.Duration{"tag-updater": time.Minute},
+	}
+	dbSchedule, emptySchedule, invalidSchedule := "*/5 * * * *", "", "61 * * * *"
+	dbJitter, noJitter := int64(30000), int64(0)
+
+	tests := []struct {
+		name     string
+		manager  *WorkerManager
+		dbWorker *models.Worker
+		spec     string
+		jitter   time.Duration
+		cron     bool
+	}{
+		{"interval", &WorkerManager{}, nil, "@every 10m0s", 0, false},
+		{"config", m, nil, "0 * * * *", time.Minute, true},
+		{"config without row overrides", m, &models.Worker{}, "0 * * * *", time.Minute, true},
+		{"row overrides config", m, &models.Worker{Schedule: &dbSchedule, JitterMs: &dbJitter},
+			"*/5 * * * *", 30 * time.Second, true},
+		{"empty row schedule keeps config", m, &models.Worker{Schedule: &emptySchedule}, "0 * * * *", time.Minute, true},
+		{"row clears jitter", m, &models.Worker{JitterMs: &noJitter}, "0 * * * *", 0, true},
+		{"invalid schedule falls back to interval", m, &models.Worker{Schedule: &invalidSchedule},
+			"@every 10m0s", time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched := tt.manager.resolveSchedule(worker, tt.dbWorker)
+			if sched.spec != tt.spec {
+				t.Errorf("spec = %q, want %q", sched.spec, tt.spec)
+			}
+			if sched.jitter != tt.jitter {
+				t.Errorf("jitter = %s, want %s", sched.jitter, tt.jitter)
+			}
+			if schedule.IsCron(sched.Schedule) != tt.cron {
+				t.Errorf("IsCron = %v, want %v", !tt.cron, tt.cron)
+			}
+		})
+	}
+}
+
+func TestScheduleNextAfter(t *testing.T) {
+	worker := stubWorker{NewBaseWorker("tag-updater", 10*time.Minute)}
+	from := time.Date(2026, 10, 17, 10, 30, 0, 0, time.UTC)
+
+	hourly, _ := schedule.Parse("@hourly")
+	if next := (workerSchedule{Schedule: hourly}).nextAfter(worker, from); !next.Equal(from.Add(30 * time.Minute)) {
+		t.Errorf("nextAfter = %s, want %s", next, from.Add(30*time.Minute))
+	}
+
+	// A schedule that never fires falls back to the worker's interval
+	never, _ := schedule.Parse("0 0 31 2 *")
+	if next := (workerSchedule{Schedule: never}).nextAfter(worker, from); !next.Equal(from.Add(10 * time.Minute)) {
+		t.Errorf("nextAfter = %s, want %s", next, from.Add(10*time.Minute))
+	}
+}
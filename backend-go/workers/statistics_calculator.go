@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"ftoolbox/config"
 	"ftoolbox/models"
+	"ftoolbox/tagfilter"
 	"time"
 
 	"go.uber.org/zap"
@@ -29,22 +30,27 @@ func NewStatisticsCalculatorWorker(db *gorm.DB, cfg *config.Config) *StatisticsC
 	}
 }
 
-func (w *StatisticsCalculatorWorker) Run(ctx context.Context) error {
+func (w *StatisticsCalculatorWorker) Run(ctx context.Context) (RunResult, error) {
 	zap.L().Info("Running statistics calculator")
 
+	var result RunResult
+
 	// Calculate tag statistics
 	if err := w.calculateTagStatistics(ctx); err != nil {
 		zap.L().Error("Failed to calculate tag statistics", zap.Error(err))
 		// Continue with creator statistics even if tag statistics failed
+	} else {
+		result.ItemsCreated++
 	}
 
 	// Calculate creator statistics
 	if err := w.calculateCreatorStatistics(ctx); err != nil {
 		zap.L().Error("Failed to calculate creator statistics", zap.Error(err))
-		return err
+		return result, err
 	}
+	result.ItemsCreated++
 
-	return nil
+	return result, nil
 }
 
 func (w *StatisticsCalculatorWorker) calculateTagStatistics(ctx context.Context) error {
@@ -60,7 +66,7 @@ func (w *StatisticsCalculatorWorker) calculateTagStatistics(ctx context.Context)
 	var totalViewCount, totalPostCount int64
 	if err := tx.Model(&models.Tag{}).
 		Where("is_deleted = ?", false).
-		Where("tag NOT LIKE ?", "%+%").
+		Scopes(tagfilter.Current(w.db).Allowed("tag", "view_count")).
 		Select("COALESCE(SUM(view_count), 0), COALESCE(SUM(post_count), 0)").
 		Row().Scan(&totalViewCount, &totalPostCount); err != nil {
 		tx.Rollback()
@@ -5,12 +5,17 @@ import (
 	"fmt"
 	"ftoolbox/config"
 	"ftoolbox/models"
+	"ftoolbox/tagfilter"
 	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// TagCleanupWorker deletes tags that are both excluded by the filter rules and too
+// small or combined by the fixed cleanup criteria. Other excluded tags are only
+// hidden and unranked, so a bad rule can be undone without losing data. Watched tags
+// are never deleted.
 type TagCleanupWorker struct {
 	BaseWorker
 	db        *gorm.DB
@@ -29,31 +34,37 @@ func NewTagCleanupWorker(db *gorm.DB, cfg *config.Config) *TagCleanupWorker {
 	}
 }
 
-func (w *TagCleanupWorker) Run(ctx context.Context) error {
+func (w *TagCleanupWorker) Run(ctx context.Context) (RunResult, error) {
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return RunResult{}, ctx.Err()
 	default:
 	}
 
-	zap.L().Info("Running tag cleanup", zap.Int64("minViews", w.minViews))
+	zap.L().Info("Running tag cleanup")
+	rules := tagfilter.Current(w.db)
 
 	var totalDeleted int64
+	result := func() RunResult {
+		return RunResult{ItemsDeleted: int(totalDeleted)}
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return result(), ctx.Err()
 		default:
 		}
 
 		var tagIDs []string
 		if err := w.db.Model(&models.Tag{}).
 			Where("view_count < ? OR tag LIKE ?", w.minViews, "%+%").
+			Where("is_watched = ?", false).
+			Scopes(rules.Excluded("tag", "view_count")).
 			Order("id").
 			Limit(w.batchSize).
 			Pluck("id", &tagIDs).Error; err != nil {
-			return fmt.Errorf("failed to load tags for cleanup: %w", err)
+			return result(), fmt.Errorf("failed to load tags for cleanup: %w", err)
 		}
 
 		if len(tagIDs) == 0 {
@@ -62,31 +73,51 @@ func (w *TagCleanupWorker) Run(ctx context.Context) error {
 			} else {
 				zap.L().Info("Tag cleanup completed", zap.Int64("deleted", totalDeleted))
 			}
-			return nil
+			return result(), nil
 		}
 
 		tx := w.db.Begin()
 		if err := tx.Where("tag_id IN (?)", tagIDs).Delete(&models.TagHistory{}).Error; err != nil {
 			tx.Rollback()
-			return fmt.Errorf("failed to delete tag history: %w", err)
+			return result(), fmt.Errorf("failed to delete tag history: %w", err)
+		}
+
+		if err := tx.Where("tag_id IN (?)", tagIDs).Delete(&models.PostTag{}).Error; err != nil {
+			tx.Rollback()
+			return result(), fmt.Errorf("failed to delete post tags: %w", err)
+		}
+
+		if err := tx.Where("tag_id IN (?)", tagIDs).Delete(&models.CreatorTagDaily{}).Error; err != nil {
+			tx.Rollback()
+			return result(), fmt.Errorf("failed to delete creator tags: %w", err)
 		}
 
 		if err := tx.Where("tag_id IN (?) OR related_tag_id IN (?)", tagIDs, tagIDs).
 			Delete(&models.TagRelationDaily{}).Error; err != nil {
 			tx.Rollback()
-			return fmt.Errorf("failed to delete tag relations: %w", err)
+			return result(), fmt.Errorf("failed to delete tag relations: %w", err)
+		}
+
+		if err := tx.Where("tag_id IN (?)", tagIDs).Delete(&models.TagStatusEvent{}).Error; err != nil {
+			tx.Rollback()
+			return result(), fmt.Errorf("failed to delete tag status events: %w", err)
+		}
+
+		if err := tx.Where("tag_id IN (?)", tagIDs).Delete(&models.TagRankDaily{}).Error; err != nil {
+			tx.Rollback()
+			return result(), fmt.Errorf("failed to delete tag ranks: %w", err)
 		}
 
-		result := tx.Where("id IN (?)", tagIDs).Delete(&models.Tag{})
-		if result.Error != nil {
+		deleted := tx.Where("id IN (?)", tagIDs).Delete(&models.Tag{})
+		if deleted.Error != nil {
 			tx.Rollback()
-			return fmt.Errorf("failed to delete tags: %w", result.Error)
+			return result(), fmt.Errorf("failed to delete tags: %w", deleted.Error)
 		}
 
 		if err := tx.Commit().Error; err != nil {
-			return fmt.Errorf("failed to commit tag cleanup: %w", err)
+			return result(), fmt.Errorf("failed to commit tag cleanup: %w", err)
 		}
 
-		totalDeleted += result.RowsAffected
+		totalDeleted += deleted.RowsAffected
 	}
 }
@@ -7,7 +7,9 @@ import (
 	"ftoolbox/config"
 	"ftoolbox/fansly"
 	"ftoolbox/models"
-	"ftoolbox/utils"
+	"ftoolbox/ratelimit"
+	"ftoolbox/services"
+	"ftoolbox/tagfilter"
 	"strings"
 	"time"
 
@@ -19,42 +21,72 @@ import (
 type TagDiscoveryWorker struct {
 	BaseWorker
 	db       *gorm.DB
-	client   *fansly.Client
+	client   fansly.API
+	tags     *services.TagService
+	creators *CreatorUpdaterWorker
 	seedTags []string
+	// seedsAdded is set once the configured seeds missing from the frontier were added
+	seedsAdded bool
+	// frontierSyncedAt is when new tags and ranks were last folded into the frontier
+	frontierSyncedAt time.Time
+	pageSize         int
+	pagesPerRun      int
+	maxPassPages     int
+	// hashtagBudget caps the hashtag lookups per run
+	hashtagBudget int
 }
 
-func NewTagDiscoveryWorker(db *gorm.DB, cfg *config.Config, client *fansly.Client) *TagDiscoveryWorker {
+func NewTagDiscoveryWorker(db *gorm.DB, cfg *config.Config, client fansly.API) *TagDiscoveryWorker {
 	interval := time.Duration(cfg.WorkerDiscoveryInterval) * time.Millisecond
 
 	return &TagDiscoveryWorker{
-		BaseWorker: NewBaseWorker("tag-discovery", interval),
-		db:         db,
-		client:     client,
-		seedTags: []string{
-			"amateur", "teen", "milf", "anal", "blonde", "brunette", "redhead",
-		},
+		BaseWorker:    NewBaseWorker("tag-discovery", interval),
+		db:            db,
+		client:        client,
+		tags:          services.NewTagService(db),
+		creators:      NewCreatorUpdaterWorker(db, cfg, client),
+		pageSize:      max(cfg.DiscoveryPageSize, 1),
+		pagesPerRun:   max(cfg.DiscoveryPagesPerRun, 1),
+		maxPassPages:  max(cfg.DiscoveryMaxPassPages, 1),
+		hashtagBudget: max(cfg.DiscoveryHashtagBudget, 0),
+		seedTags:      cfg.DiscoverySeedTags,
 	}
 }
 
-func (w *TagDiscoveryWorker) Run(ctx context.Context) error {
+func (w *TagDiscoveryWorker) UsesFansly() bool {
+	return true
+}
+
+func (w *TagDiscoveryWorker) Run(ctx context.Context) (RunResult, error) {
 	zap.L().Info("Running tag discovery")
+	ctx = ratelimit.WithPriority(ctx, ratelimit.PriorityBulk)
 
 	// Get a tag to use for discovery
+	var result RunResult
 	tagToUse, err := w.getTagForDiscovery()
 	if err != nil {
-		return fmt.Errorf("failed to get tag for discovery: %w", err)
+		return result, fmt.Errorf("failed to get tag for discovery: %w", err)
 	}
 
 	if tagToUse == "" {
 		zap.L().Debug("No suitable tag found for discovery")
-		return nil
+		return result, nil
 	}
 
 	zap.L().Info("Discovering tags from", zap.String("source_tag", tagToUse))
 
-	// Always update the last_used_for_discovery timestamp to prevent getting stuck
-	// on the same tag if it fails
+	// Always update the last_used_for_discovery timestamp and the frontier stats to
+	// prevent getting stuck on the same tag if it fails
+	pages := 0
+	pagesCreated := 0
+	var yield *float64
 	defer func() {
+		if pages > 0 {
+			perPage := float64(pagesCreated) / float64(pages)
+			yield = &perPage
+		}
+		w.recordFrontierRun(tagToUse, pages, result, yield)
+
 		now := time.Now()
 		if err := w.db.Model(&models.Tag{}).
 			Where("tag = ?", tagToUse).
@@ -70,121 +102,146 @@ func (w *TagDiscoveryWorker) Run(ctx context.Context) error {
 	if err != nil {
 		// If tag not found on Fansly, mark it as deleted
 		if errors.Is(err, fansly.ErrTagNotFound) {
-			zap.L().Info("Tag no longer exists on Fansly, marking as deleted",
-				zap.String("tag", tagToUse))
-
-			// Update the tag to mark it as deleted
-			now := time.Now()
-			updates := map[string]any{
-				"is_deleted":          true,
-				"deleted_detected_at": &now,
-				"updated_at":          now,
-			}
-
-			if updateErr := w.db.Model(&models.Tag{}).
-				Where("tag = ?", tagToUse).
-				Updates(updates).Error; updateErr != nil {
+			deleted, markErr := w.tags.MarkDeleted(tagToUse, nil)
+			if markErr != nil {
 				zap.L().Error("Failed to mark tag as deleted",
 					zap.String("tag", tagToUse),
-					zap.Error(updateErr))
+					zap.Error(markErr))
 			}
+			if deleted {
+				result.ItemsDeleted++
+			}
+			noYield := 0.0
+			yield = &noYield
 
 			// Continue with discovery using another tag
-			return nil
+			return result, nil
 		}
-		return fmt.Errorf("failed to fetch tag details: %w", err)
+		return result, fmt.Errorf("failed to fetch tag details: %w", err)
 	}
 
-	// Fetch posts for this tag using its ID
-	result, err := w.client.GetSuggestionsData(ctx, []string{tagDetails.MediaOfferSuggestionTag.ID}, "0", "0", 20, 0)
+	// Page through the tag's posts, continuing where the previous run stopped
+	sourceTagID := tagDetails.MediaOfferSuggestionTag.ID
+	progress, err := w.loadProgress(sourceTagID)
 	if err != nil {
-		return fmt.Errorf("failed to fetch posts: %w", err)
+		return result, err
 	}
 
-	// Extract and process tags from mediaOfferSuggestions
-	discoveredTags := w.extractTagsFromSuggestions(result.MediaOfferSuggestions)
-	newTags := 0
+	for pages < w.pagesPerRun {
+		suggestions, err := w.fetchPage(ctx, progress)
+		if err != nil {
+			return result, fmt.Errorf("failed to fetch posts: %w", err)
+		}
+		pages++
+
+		pageResult, err := w.processPage(ctx, sourceTagID, suggestions)
+		result.ItemsProcessed += pageResult.ItemsProcessed
+		result.ItemsCreated += pageResult.ItemsCreated
+		pagesCreated += pageResult.ItemsCreated
+		if err != nil {
+			return result, err
+		}
+
+		passDone := w.advanceProgress(progress, suggestions)
+		if err := w.db.Save(progress).Error; err != nil {
+			zap.L().Error("Failed to save discovery progress", zap.String("tag", tagToUse), zap.Error(err))
+		}
+		if passDone {
+			break
+		}
+	}
+
+	// Look up hashtags mined from post content, within the per-run budget
+	contentTags, err := w.resolveHashtags(ctx)
+	result.ItemsCreated += contentTags
+	if err != nil {
+		zap.L().Error("Failed to resolve hashtags", zap.Error(err))
+	}
+
+	// Purge old relation buckets beyond 2 days
+	if err := w.purgeOldTagRelations(2); err != nil {
+		zap.L().Error("Failed to purge old tag relations", zap.Error(err))
+	}
+
+	zap.L().Info("Tag discovery completed",
+		zap.String("source_tag", tagToUse),
+		zap.Int("pages", pages),
+		zap.Int("pass_pages", progress.PagesRead),
+		zap.Int("discovered", result.ItemsProcessed),
+		zap.Int("new", result.ItemsCreated),
+		zap.Int("new_from_content", contentTags))
+
+	return result, nil
+}
 
+// processPage stores the tags, co-usage counts and creators found on one page of suggestions
+func (w *TagDiscoveryWorker) processPage(ctx context.Context, sourceTagID string, suggestions *fansly.SuggestionsResponseData) (RunResult, error) {
+	var result RunResult
+	if suggestions == nil {
+		return result, nil
+	}
+
+	// Extract and process tags from mediaOfferSuggestions
+	discoveredTags := w.extractTagsFromSuggestions(suggestions.MediaOfferSuggestions)
 	for _, tag := range discoveredTags {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return result, ctx.Err()
 		default:
 		}
 
-		if err := w.processDiscoveredTag(tag); err != nil {
+		created, err := w.processDiscoveredTag(tag)
+		if err != nil {
 			zap.L().Error("Failed to process discovered tag",
 				zap.String("tag", tag.Tag),
 				zap.Error(err))
 			continue
-		} else {
-			newTags++
+		}
+		result.ItemsProcessed++
+		if created {
+			result.ItemsCreated++
 		}
 	}
 
 	// Update related tag relations from the suggestions
-	if err := w.updateTagRelationsFromSuggestions(ctx, tagDetails.MediaOfferSuggestionTag.ID, result.MediaOfferSuggestions); err != nil {
+	if err := w.updateTagRelationsFromSuggestions(ctx, sourceTagID, suggestions.MediaOfferSuggestions); err != nil {
 		zap.L().Error("Failed to update tag relations", zap.Error(err))
 	}
 
-	// Purge old relation buckets beyond 2 days
-	if err := w.purgeOldTagRelations(2); err != nil {
-		zap.L().Error("Failed to purge old tag relations", zap.Error(err))
+	// Collect hashtags from post content for later lookup
+	if suggestions.AggregationData != nil {
+		if err := w.collectHashtags(suggestions.AggregationData.Posts); err != nil {
+			zap.L().Error("Failed to collect hashtags", zap.Error(err))
+		}
 	}
 
-	zap.L().Info("Tag discovery completed",
-		zap.String("source_tag", tagToUse),
-		zap.Int("discovered", len(discoveredTags)),
-		zap.Int("new", newTags))
-
-	tempCreatorWorker := NewCreatorUpdaterWorker(w.db, w.client)
+	// Store the page's posts and their engagement
+	if newPosts, err := w.storePosts(suggestions); err != nil {
+		zap.L().Error("Failed to store posts", zap.Error(err))
+	} else if newPosts > 0 {
+		zap.L().Debug("Stored new posts", zap.Int("count", newPosts))
+	}
 
-	// Discover creators from the same tag
-	if result.AggregationData != nil && result.AggregationData.Accounts != nil {
-		if err := tempCreatorWorker.ProcessCreators(result.AggregationData.Accounts); err != nil {
+	// Discover creators from the same page
+	if suggestions.AggregationData != nil && suggestions.AggregationData.Accounts != nil {
+		if err := w.creators.ProcessCreators(suggestions.AggregationData.Accounts); err != nil {
 			zap.L().Error("Failed to discover creators", zap.Error(err))
 			// Don't return error, as tag discovery succeeded
 		}
 	}
 
-	return nil
-}
-
-func (w *TagDiscoveryWorker) getTagForDiscovery() (string, error) {
-	// First, try to get a tag from the database that hasn't been used recently
-	hoursAgo := time.Now().Add(-3 * time.Hour)
-
-	var tags []models.Tag
-	// Get multiple tags that haven't been used recently and aren't deleted, ordered by rank
-	if err := w.db.Where("(last_used_for_discovery IS NULL OR last_used_for_discovery < ?) AND is_deleted = ?", hoursAgo, false).
-		Where("tag NOT LIKE ?", "%&%").
-		Where("tag NOT LIKE ?", "%+%").
-		Order("rank ASC").
-		Limit(10).
-		Find(&tags).Error; err == nil && len(tags) > 0 {
-		// Select a random tag from the top 10 to avoid always picking the same one
-		idx := time.Now().Unix() % int64(len(tags))
-		return tags[idx].Tag, nil
-	}
-
-	// If no suitable database tag, use a random seed tag
-	if len(w.seedTags) > 0 {
-		// Simple random selection using current time
-		idx := time.Now().Unix() % int64(len(w.seedTags))
-		return w.seedTags[idx], nil
-	}
-
-	return "", fmt.Errorf("no tags available for discovery")
+	return result, nil
 }
 
 // extractTagsFromSuggestions extracts unique tags from media offer suggestions
 func (w *TagDiscoveryWorker) extractTagsFromSuggestions(suggestions []fansly.MediaOfferSuggestion) []fansly.FanslyTag {
 	tagMap := make(map[string]fansly.FanslyTag)
+	rules := tagfilter.Current(w.db)
 
 	for _, suggestion := range suggestions {
 		for _, tag := range suggestion.PostTags {
 			tagName := strings.ToLower(strings.TrimSpace(tag.Tag))
-			if !shouldSkipDiscoveredTagName(tagName) {
+			if !rules.Excludes(tagName, tag.ViewCount) {
 				tagMap[tagName] = tag
 			}
 		}
@@ -199,36 +256,26 @@ func (w *TagDiscoveryWorker) extractTagsFromSuggestions(suggestions []fansly.Med
 	return tags
 }
 
-func (w *TagDiscoveryWorker) processDiscoveredTag(tag fansly.FanslyTag) error {
-	if shouldSkipDiscoveredTagName(tag.Tag) {
-		return nil
-	}
-	// Check if tag already exists
-	var existingTag models.Tag
-	if err := w.db.Where("tag = ?", tag.Tag).First(&existingTag).Error; err == nil {
-		// Tag already exists
-		return nil
+// processDiscoveredTag stores a discovered tag and reports whether it was new
+func (w *TagDiscoveryWorker) processDiscoveredTag(tag fansly.FanslyTag) (bool, error) {
+	if tagfilter.Current(w.db).Excludes(tag.Tag, tag.ViewCount) {
+		return false, nil
 	}
-
-	// Create new tag using the data we already have
-	newTag := models.Tag{
-		ID:              tag.ID,
-		Tag:             tag.Tag,
-		ViewCount:       tag.ViewCount,
-		PostCount:       tag.PostCount,
-		FanslyCreatedAt: fansly.ParseFanslyTimestamp(tag.CreatedAt),
+	newTag, created, err := w.tags.Create(&tag, services.TagCreateOptions{Source: models.TagSourceSuggestions})
+	if err != nil {
+		return false, err
 	}
-
-	if err := w.db.Create(&newTag).Error; err != nil {
-		return fmt.Errorf("failed to create tag: %w", err)
+	if !created {
+		return false, nil
 	}
+	w.addToFrontier(newTag.Tag)
 
 	zap.L().Info("Discovered new tag",
 		zap.String("tag", tag.Tag),
 		zap.Int64("viewCount", tag.ViewCount),
 		zap.Int64("postCount", tag.PostCount))
 
-	return nil
+	return true, nil
 }
 
 // updateTagRelationsFromSuggestions records co-usage counts for all tags observed together per day.
@@ -246,13 +293,14 @@ func (w *TagDiscoveryWorker) updateTagRelationsFromSuggestions(ctx context.Conte
 	// Aggregate per (source, related, date)
 	type key struct{ source, related string }
 	counts := make(map[key]int64)
+	rules := tagfilter.Current(w.db)
 
 	for _, s := range suggestions {
 		// Build a unique set of tag IDs observed in this suggestion
 		seen := make(map[string]struct{})
 		for _, t := range s.PostTags {
 			tagName := strings.ToLower(strings.TrimSpace(t.Tag))
-			if shouldSkipDiscoveredTagName(tagName) {
+			if rules.Excludes(tagName, t.ViewCount) {
 				continue
 			}
 			id := strings.TrimSpace(t.ID)
@@ -327,7 +375,3 @@ func (w *TagDiscoveryWorker) purgeOldTagRelations(windowDays int) error {
 	// Recalculate tag ranks/heat only if needed; not required for relations
 	return nil
 }
-
-func shouldSkipDiscoveredTagName(tagName string) bool {
-	return tagName == "" || strings.Contains(tagName, "&") || utils.TagNameHasPlus(tagName)
-}
@@ -0,0 +1,102 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"ftoolbox/database/testdb"
+	"ftoolbox/fansly"
+	"ftoolbox/models"
+	"slices"
+	"testing"
+)
+
+// discoverySuggestions is one page of posts tagged #feet: toes is new, a+b and
+// small are excluded by the default filter rules
+const discoverySuggestions = `{
+	"mediaOfferSuggestions": [
+		{"id": "5", "postTags": [
+			{"id": "100", "tag": "feet", "viewCount": 10000},
+			{"id": "101", "tag": "toes", "viewCount": 5000},
+			{"id": "102", "tag": "a+b", "viewCount": 9000}
+		]},
+		{"id": "4", "postTags": [
+			{"id": "100", "tag": "feet", "viewCount": 10000},
+			{"id": "103", "tag": "small", "viewCount": 10}
+		]}
+	],
+	"aggregationData": {
+		"accounts": [{"id": "10", "username": "alice", "followCount": 150}]
+	}
+}`
+
+func TestTagDiscoveryRun(t *testing.T) {
+	db := testdb.Open(t)
+	server, client := newFakeFansly(t)
+	server.AddTag(fansly.FanslyTag{ID: "100", Tag: "feet", ViewCount: 10000})
+
+	var suggestions fansly.SuggestionsResponseData
+	if err := json.Unmarshal([]byte(discoverySuggestions), &suggestions); err != nil {
+		t.Fatal(err)
+	}
+	server.SetSuggestions([]string{"100"}, suggestions)
+
+	cfg := testConfig()
+	cfg.DiscoverySeedTags = []string{"feet"}
+	result, err := NewTagDiscoveryWorker(db, cfg, client).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	if result.ItemsProcessed != 2 || result.ItemsCreated != 2 {
+		t.Errorf("Run() = %+v, want 2 processed and 2 created", result)
+	}
+
+	var tags []string
+	if err := db.Model(&models.Tag{}).Order("tag").Pluck("tag", &tags).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(tags, []string{"feet", "toes"}) {
+		t.Errorf("stored tags = %q, want feet and toes", tags)
+	}
+
+	var frontier []models.DiscoveryFrontier
+	if err := db.Order("tag").Find(&frontier).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(frontier) != 2 || frontier[0].Tag != "feet" || frontier[1].Tag != "toes" {
+		t.Fatalf("frontier = %+v, want feet and toes", frontier)
+	}
+	feet := frontier[0]
+	if !feet.IsSeed || feet.Runs != 1 || feet.PagesRead != 1 || feet.NewTagsFound != 2 || feet.LastUsedAt == nil {
+		t.Errorf("feet frontier row = %+v, want a seed with one run of one page finding 2 new tags", feet)
+	}
+	// The first run sets the novelty to its yield rather than averaging it in
+	if feet.Novelty != 2 {
+		t.Errorf("feet novelty = %v, want 2", feet.Novelty)
+	}
+
+	var relations int64
+	if err := db.Model(&models.TagRelationDaily{}).
+		Where("tag_id = ? AND related_tag_id = ?", "100", "101").
+		Count(&relations).Error; err != nil {
+		t.Fatal(err)
+	}
+	if relations != 1 {
+		t.Errorf("feet -> toes relations = %d, want 1", relations)
+	}
+
+	var creator models.Creator
+	if err := db.First(&creator, "id = ?", "10").Error; err != nil {
+		t.Fatalf("discovered creator not stored: %v", err)
+	}
+	if creator.Followers != 150 {
+		t.Errorf("alice followers = %d, want 150", creator.Followers)
+	}
+
+	var progress models.DiscoveryProgress
+	if err := db.First(&progress, "tag_id = ?", "100").Error; err != nil {
+		t.Fatalf("discovery progress not stored: %v", err)
+	}
+	if progress.PassesDone != 1 {
+		t.Errorf("passes done = %d, want 1 after a short page", progress.PassesDone)
+	}
+}
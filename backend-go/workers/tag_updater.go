@@ -7,158 +7,336 @@ import (
 	"ftoolbox/config"
 	"ftoolbox/fansly"
 	"ftoolbox/models"
+	"ftoolbox/ratelimit"
+	"ftoolbox/services"
+	"ftoolbox/tagfilter"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+const (
+	// tagActivityWindow is how far back tag_history is compared to measure activity
+	tagActivityWindow = 7 * 24 * time.Hour
+	// tagActivityMinSpan is the shortest history span trusted for an activity estimate
+	tagActivityMinSpan = time.Hour
+	// defaultTagRefreshInterval applies until a tag has enough history
+	defaultTagRefreshInterval = 24 * time.Hour
+	// watchedTagMaxInterval caps the interval of tags users asked to track
+	watchedTagMaxInterval = 3 * time.Hour
+)
+
+// topTagRankCaps caps the refresh interval of highly ranked tags
+var topTagRankCaps = []rankCap{
+	{100, 6 * time.Hour},
+	{1000, 24 * time.Hour},
+}
+
 type TagUpdaterWorker struct {
 	BaseWorker
-	db     *gorm.DB
-	client *fansly.Client
+	db        *gorm.DB
+	client    fansly.API
+	tags      *services.TagService
+	batchSize int
+	// concurrency caps the Fansly lookups in flight during a run
+	concurrency int
+	policy      refreshPolicy
+	load        refreshLoad
 }
 
-func NewTagUpdaterWorker(db *gorm.DB, cfg *config.Config, client *fansly.Client) *TagUpdaterWorker {
+func NewTagUpdaterWorker(db *gorm.DB, cfg *config.Config, client fansly.API) *TagUpdaterWorker {
 	interval := time.Duration(cfg.WorkerUpdateInterval) * time.Millisecond
 
 	return &TagUpdaterWorker{
-		BaseWorker: NewBaseWorker("tag-updater", interval),
-		db:         db,
-		client:     client,
+		BaseWorker:  NewBaseWorker("tag-updater", interval),
+		db:          db,
+		client:      client,
+		tags:        services.NewTagService(db),
+		batchSize:   max(cfg.TagRefreshBatchSize, 1),
+		concurrency: max(cfg.TagRefreshConcurrency, 1),
+		policy: refreshPolicy{
+			minInterval:      time.Duration(cfg.TagRefreshMinInterval) * time.Millisecond,
+			maxInterval:      time.Duration(cfg.TagRefreshMaxInterval) * time.Millisecond,
+			hotActivity:      0.02,   // 2% more views or posts per day
+			stagnantActivity: 0.0001, // 0.01% per day
+		},
 	}
 }
 
-func (w *TagUpdaterWorker) Run(ctx context.Context) error {
+func (w *TagUpdaterWorker) UsesFansly() bool {
+	return true
+}
+
+func (w *TagUpdaterWorker) Run(ctx context.Context) (RunResult, error) {
 	zap.L().Info("Running tag updater")
+	ctx = ratelimit.WithPriority(ctx, ratelimit.PriorityScheduled)
 
-	// Get tags that need updating (haven't been checked in 24 hours)
-	twentyFourHoursAgo := time.Now().Add(-24 * time.Hour)
+	// Get tags whose refresh is due; never-scheduled tags sort first
+	now := time.Now()
 
 	var tags []models.Tag
-	if err := w.db.Where("last_checked_at IS NULL OR last_checked_at < ?", twentyFourHoursAgo).
-		Where("tag NOT LIKE ?", "%+%").
+	if err := w.dueTags(now).
+		Order("is_watched DESC").
+		Order("next_refresh_at ASC").
 		Order("view_count DESC").
-		Limit(20).
+		Limit(w.batchSize).
 		Find(&tags).Error; err != nil {
-		return fmt.Errorf("failed to fetch tags: %w", err)
+		return RunResult{}, fmt.Errorf("failed to fetch tags: %w", err)
 	}
 
+	var result RunResult
 	if len(tags) == 0 {
 		zap.L().Debug("No tags need updating")
-		return nil
+		return result, nil
 	}
 
-	zap.L().Info("Updating tags", zap.Int("count", len(tags)))
+	capacityPerHour := float64(w.batchSize) * float64(time.Hour) / float64(w.Interval())
+	loadFactor := w.load.get(w.Name(), capacityPerHour, w.dueTags(now.Add(-time.Hour)).Model(&models.Tag{}))
 
-	for _, tag := range tags {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
+	zap.L().Info("Updating tags",
+		zap.Int("count", len(tags)),
+		zap.Int("concurrency", w.concurrency),
+		zap.Float64("load_factor", loadFactor))
+
+	fetches := w.fetchTags(ctx, tags)
+
+	var (
+		refreshed []tagFetch
+		failedIDs []string
+		stopErr   error
+	)
+	for _, fetch := range fetches {
+		switch {
+		case fetch.tag == nil:
+			// Never fetched because the run was cut short; the tag stays due
+		case fetch.err == nil:
+			refreshed = append(refreshed, fetch)
+		case errors.Is(fetch.err, fansly.ErrTagNotFound):
+			// Deleted tags are still checked weekly in case they come back
+			nextRefresh := time.Now().Add(w.policy.maxInterval)
+			deleted, err := w.tags.MarkDeleted(fetch.tag.Tag, &nextRefresh)
+			if err != nil {
+				zap.L().Error("Failed to mark tag as deleted", zap.String("tag", fetch.tag.Tag), zap.Error(err))
+				continue
+			}
+			result.ItemsProcessed++
+			if deleted {
+				result.ItemsDeleted++
+			}
+		case errors.Is(fetch.err, fansly.ErrUnavailable):
+			// An outage says nothing about the tag; leave it due so it is retried
+			stopErr = fetch.err
+		case ctx.Err() != nil || errors.Is(fetch.err, context.Canceled):
+			// Cancelled with the rest of the run; the tag stays due
 		default:
+			zap.L().Error("Failed to update tag",
+				zap.String("tag", fetch.tag.Tag),
+				zap.Error(fetch.err))
+			failedIDs = append(failedIDs, fetch.tag.ID)
 		}
+	}
 
-		if err := w.updateTag(ctx, &tag); err != nil {
-			zap.L().Error("Failed to update tag",
-				zap.String("tag", tag.Tag),
-				zap.Error(err))
-
-			// Update last_checked_at even on error to avoid immediate retries
-			now := time.Now()
-			tag.LastCheckedAt = &now
-			if updateErr := w.db.Save(&tag).Error; updateErr != nil {
-				zap.L().Error("Failed to update last checked time after error",
-					zap.String("tag", tag.Tag),
-					zap.Error(updateErr))
+	refreshedCount, err := w.storeRefreshes(refreshed, loadFactor)
+	result.ItemsProcessed += refreshedCount
+	if err != nil {
+		return result, err
+	}
+	w.deferFailedTags(failedIDs)
+	result.ItemsFailed = len(failedIDs)
+
+	elapsed := time.Since(now)
+	zap.L().Info("Tag updater run completed",
+		zap.Int("due", len(tags)),
+		zap.Int("refreshed", refreshedCount),
+		zap.Int("deleted", result.ItemsDeleted),
+		zap.Int("failed", result.ItemsFailed),
+		zap.Duration("duration", elapsed),
+		zap.Float64("tags_per_minute", float64(result.ItemsProcessed)/max(elapsed.Minutes(), 1e-9)))
+
+	if stopErr != nil {
+		return result, fmt.Errorf("stopping tag updates: %w", stopErr)
+	}
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+	return result, nil
+}
+
+// dueTags selects tags whose refresh is due at t
+func (w *TagUpdaterWorker) dueTags(t time.Time) *gorm.DB {
+	return w.db.Where("next_refresh_at IS NULL OR next_refresh_at <= ?", t).
+		Scopes(tagfilter.Current(w.db).Allowed("tag", "view_count"))
+}
+
+// tagFetch is the outcome of looking up one due tag on Fansly
+type tagFetch struct {
+	tag   *models.Tag
+	fresh *fansly.FanslyTag
+	err   error
+}
+
+// fetchTags looks the tags up on Fansly with up to w.concurrency requests in flight;
+// the shared rate limiter still paces them. An outage stops the remaining lookups,
+// whose results are left with a nil tag.
+func (w *TagUpdaterWorker) fetchTags(ctx context.Context, tags []models.Tag) []tagFetch {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fetches := make([]tagFetch, len(tags))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(w.concurrency, len(tags)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fetch := tagFetch{tag: &tags[i]}
+				response, err := w.client.GetTagWithContext(ctx, tags[i].Tag)
+				switch {
+				case err != nil:
+					fetch.err = err
+				case response == nil || response.MediaOfferSuggestionTag == nil:
+					fetch.err = fmt.Errorf("failed to fetch view count: empty response")
+				default:
+					fetch.fresh = response.MediaOfferSuggestionTag
+				}
+				if errors.Is(err, fansly.ErrUnavailable) {
+					cancel()
+				}
+				fetches[i] = fetch
 			}
-			continue
+		}()
+	}
+
+feed:
+	for i := range tags {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
 		}
 	}
+	close(jobs)
+	wg.Wait()
 
-	return nil
+	return fetches
 }
 
-func (w *TagUpdaterWorker) updateTag(ctx context.Context, tag *models.Tag) error {
-	// Fetch current view count from Fansly
-	viewCount, err := w.client.GetTagWithContext(ctx, tag.Tag)
-	if err != nil {
-		// Check if tag no longer exists on Fansly
-		if errors.Is(err, fansly.ErrTagNotFound) {
-			// Mark tag as deleted
-			now := time.Now()
-			tag.LastCheckedAt = &now
-			tag.UpdatedAt = now
-
-			// Only update deletion fields if not already marked as deleted
-			if !tag.IsDeleted {
-				tag.IsDeleted = true
-				tag.DeletedDetectedAt = &now
-
-				zap.L().Info("Tag no longer exists on Fansly, marking as deleted",
-					zap.String("tag", tag.Tag))
-			}
+// storeRefreshes schedules the refreshed tags from their growth and writes them in
+// batches, returning how many were stored
+func (w *TagUpdaterWorker) storeRefreshes(fetches []tagFetch, loadFactor float64) (int, error) {
+	if len(fetches) == 0 {
+		return 0, nil
+	}
 
-			// Save the updated tag (no history entry for deleted tags)
-			if err := w.db.Save(tag).Error; err != nil {
-				return fmt.Errorf("failed to update deleted tag: %w", err)
-			}
+	now := time.Now()
+	ids := make([]string, len(fetches))
+	for i, fetch := range fetches {
+		ids[i] = fetch.tag.ID
+	}
+	oldest, err := w.oldestHistory(ids, now.Add(-tagActivityWindow))
+	if err != nil {
+		zap.L().Warn("Failed to load tag history for scheduling", zap.Error(err))
+	}
 
-			return nil
+	refreshes := make([]services.TagRefresh, len(fetches))
+	previous := make([]models.Tag, len(fetches))
+	for i, fetch := range fetches {
+		var history *models.TagHistory
+		if h, ok := oldest[fetch.tag.ID]; ok {
+			history = &h
+		}
+		previous[i] = *fetch.tag
+		refreshes[i] = services.TagRefresh{
+			Tag:         fetch.tag,
+			Fresh:       fetch.fresh,
+			NextRefresh: now.Add(w.refreshInterval(fetch.tag, fetch.fresh, history, now, loadFactor)),
 		}
-		return fmt.Errorf("failed to fetch view count: %w", err)
 	}
 
-	// If tag was previously deleted but now exists again, clear the deletion flag
-	if tag.IsDeleted {
-		tag.IsDeleted = false
-		tag.DeletedDetectedAt = nil
-		zap.L().Info("Tag exists again on Fansly, clearing deleted status",
-			zap.String("tag", tag.Tag))
+	if err := w.tags.RefreshBatch(refreshes); err != nil {
+		return 0, fmt.Errorf("failed to store tag refreshes: %w", err)
 	}
 
-	// Calculate changes
-	viewCountChange := viewCount.MediaOfferSuggestionTag.ViewCount - tag.ViewCount
-	postCountChange := viewCount.MediaOfferSuggestionTag.PostCount - tag.PostCount
+	for i, refresh := range refreshes {
+		zap.L().Debug("Updated tag",
+			zap.String("tag", refresh.Tag.Tag),
+			zap.Int64("viewCount", refresh.Tag.ViewCount),
+			zap.Int64("viewCountChange", refresh.Tag.ViewCount-previous[i].ViewCount),
+			zap.Int64("postCount", refresh.Tag.PostCount),
+			zap.Int64("postCountChange", refresh.Tag.PostCount-previous[i].PostCount),
+			zap.Time("nextRefreshAt", refresh.NextRefresh))
+	}
+	return len(refreshes), nil
+}
 
-	// Start transaction
-	tx := w.db.Begin()
+// deferFailedTags pushes tags whose lookup failed back by the shortest interval to
+// avoid immediate retries
+func (w *TagUpdaterWorker) deferFailedTags(ids []string) {
+	if len(ids) == 0 {
+		return
+	}
 
-	// Update tag
 	now := time.Now()
-	tag.ViewCount = viewCount.MediaOfferSuggestionTag.ViewCount
-	tag.PostCount = viewCount.MediaOfferSuggestionTag.PostCount
-	tag.LastCheckedAt = &now
-	tag.UpdatedAt = now
-
-	if err := tx.Save(tag).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to update tag: %w", err)
+	if err := w.db.Model(&models.Tag{}).Where("id IN ?", ids).Updates(map[string]any{
+		"last_checked_at": now,
+		"next_refresh_at": now.Add(w.policy.minInterval),
+	}).Error; err != nil {
+		zap.L().Error("Failed to update last checked time after error",
+			zap.Int("tags", len(ids)),
+			zap.Error(err))
 	}
+}
 
-	history := models.TagHistory{
-		TagID:           tag.ID,
-		ViewCount:       viewCount.MediaOfferSuggestionTag.ViewCount,
-		Change:          viewCountChange,
-		PostCount:       viewCount.MediaOfferSuggestionTag.PostCount,
-		PostCountChange: postCountChange,
+// oldestHistory returns each tag's oldest history point since t, keyed by tag ID
+func (w *TagUpdaterWorker) oldestHistory(tagIDs []string, since time.Time) (map[string]models.TagHistory, error) {
+	oldest := make(map[string]models.TagHistory, len(tagIDs))
+	if len(tagIDs) == 0 {
+		return oldest, nil
 	}
 
-	if err := tx.Create(&history).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to create history: %w", err)
+	var rows []models.TagHistory
+	if err := w.db.Raw(`
+		SELECT h.*
+		FROM tag_history h
+		JOIN (
+			SELECT tag_id, MIN(created_at) AS created_at
+			FROM tag_history
+			WHERE tag_id IN ? AND created_at >= ?
+			GROUP BY tag_id
+		) o ON o.tag_id = h.tag_id AND o.created_at = h.created_at
+	`, tagIDs, since).Scan(&rows).Error; err != nil {
+		return oldest, err
+	}
+	for _, row := range rows {
+		oldest[row.TagID] = row
 	}
+	return oldest, nil
+}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+// refreshInterval picks the time until a tag's next refresh from its view and post
+// growth since its oldest history point within tagActivityWindow, tightened for
+// watched and highly ranked tags
+func (w *TagUpdaterWorker) refreshInterval(tag *models.Tag, current *fansly.FanslyTag, oldest *models.TagHistory, now time.Time, loadFactor float64) time.Duration {
+	interval := defaultTagRefreshInterval
+
+	if oldest != nil {
+		if span := now.Sub(oldest.CreatedAt); span >= tagActivityMinSpan {
+			activity := max(
+				relativeGrowthPerDay(oldest.ViewCount, current.ViewCount, span),
+				relativeGrowthPerDay(oldest.PostCount, current.PostCount, span),
+			)
+			interval = w.policy.intervalForActivity(activity)
+		}
 	}
 
-	zap.L().Debug("Updated tag",
-		zap.String("tag", tag.Tag),
-		zap.Int64("viewCount", viewCount.MediaOfferSuggestionTag.ViewCount),
-		zap.Int64("viewCountChange", viewCountChange),
-		zap.Int64("postCount", viewCount.MediaOfferSuggestionTag.PostCount),
-		zap.Int64("postCountChange", postCountChange))
+	if tag.IsWatched {
+		interval = min(interval, watchedTagMaxInterval)
+	}
+	interval = capByRank(interval, tag.Rank, topTagRankCaps)
 
-	return nil
+	return w.policy.finalize(interval, loadFactor)
 }
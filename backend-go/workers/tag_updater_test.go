@@ -0,0 +1,112 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"ftoolbox/database/testdb"
+	"ftoolbox/fansly"
+	"ftoolbox/models"
+	"ftoolbox/services"
+	"testing"
+	"time"
+)
+
+func TestTagUpdaterRun(t *testing.T) {
+	db := testdb.Open(t)
+	server, client := newFakeFansly(t)
+	server.AddTag(fansly.FanslyTag{ID: "1", Tag: "feet", ViewCount: 2000, PostCount: 20})
+
+	tags := services.NewTagService(db)
+	for _, tag := range []fansly.FanslyTag{
+		{ID: "1", Tag: "feet", ViewCount: 1000, PostCount: 10},
+		{ID: "2", Tag: "gone", ViewCount: 1000, PostCount: 10},
+	} {
+		if _, _, err := tags.Create(&tag, services.TagCreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Model(&models.Tag{}).Where("1 = 1").
+		Update("next_refresh_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	result, err := NewTagUpdaterWorker(db, testConfig(), client).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	if result.ItemsProcessed != 2 || result.ItemsDeleted != 1 || result.ItemsFailed != 0 {
+		t.Errorf("Run() = %+v, want 2 processed and 1 deleted", result)
+	}
+	if got := server.RequestCount("/contentdiscovery/media/tag"); got != 2 {
+		t.Errorf("tag lookups = %d, want 2", got)
+	}
+
+	var feet models.Tag
+	if err := db.First(&feet, "id = ?", "1").Error; err != nil {
+		t.Fatal(err)
+	}
+	if feet.ViewCount != 2000 || feet.PostCount != 20 || feet.IsDeleted {
+		t.Errorf("feet = %d views, %d posts, deleted %v; want 2000, 20, false", feet.ViewCount, feet.PostCount, feet.IsDeleted)
+	}
+	if feet.NextRefreshAt == nil || !feet.NextRefreshAt.After(start) {
+		t.Errorf("feet next refresh = %v, want after the run", feet.NextRefreshAt)
+	}
+
+	var history []models.TagHistory
+	if err := db.Where("tag_id = ?", "1").Order("id").Find(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].ViewCount != 2000 || history[1].PostCount != 20 {
+		t.Errorf("feet history = %+v, want a second point with 2000 views and 20 posts", history)
+	}
+
+	var gone models.Tag
+	if err := db.First(&gone, "id = ?", "2").Error; err != nil {
+		t.Fatal(err)
+	}
+	if !gone.IsDeleted || gone.DeletedDetectedAt == nil {
+		t.Errorf("gone is not marked deleted")
+	}
+
+	var events []models.TagStatusEvent
+	if err := db.Where("tag_id = ?", "2").Find(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Event != models.TagEventBanned {
+		t.Errorf("gone events = %+v, want one ban", events)
+	}
+}
+
+func TestTagUpdaterRunRateLimited(t *testing.T) {
+	db := testdb.Open(t)
+	server, client := newFakeFansly(t)
+	server.AddTag(fansly.FanslyTag{ID: "1", Tag: "feet", ViewCount: 2000, PostCount: 20})
+	server.Throttle(1000, 0)
+
+	tag := fansly.FanslyTag{ID: "1", Tag: "feet", ViewCount: 1000, PostCount: 10}
+	if _, _, err := services.NewTagService(db).Create(&tag, services.TagCreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	due := time.Now().Add(-time.Minute)
+	if err := db.Model(&models.Tag{}).Where("id = ?", "1").Update("next_refresh_at", due).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewTagUpdaterWorker(db, testConfig(), client).Run(context.Background())
+	if !errors.Is(err, fansly.ErrUnavailable) {
+		t.Fatalf("Run() = %v, want ErrUnavailable", err)
+	}
+	if result.ItemsFailed != 0 {
+		t.Errorf("Run() = %+v, want no failed tags", result)
+	}
+
+	// Being rate limited says nothing about the tag, so it stays due
+	var feet models.Tag
+	if err := db.First(&feet, "id = ?", "1").Error; err != nil {
+		t.Fatal(err)
+	}
+	if feet.ViewCount != 1000 || feet.NextRefreshAt == nil || feet.NextRefreshAt.After(time.Now()) {
+		t.Errorf("feet = %d views, next refresh %v; want unchanged and still due", feet.ViewCount, feet.NextRefreshAt)
+	}
+}
@@ -0,0 +1,154 @@
+package workers
+
+import (
+	"fmt"
+	"ftoolbox/models"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultWorkerTimeout bounds a single run of workers without a WORKER_TIMEOUTS entry
+const defaultWorkerTimeout = time.Hour
+
+// interruptedRunError is stored on runs whose instance stopped before they finished
+const interruptedRunError = "run interrupted: instance stopped before the run completed"
+
+// timeoutFor returns the deadline for a single run of the worker
+func (m *WorkerManager) timeoutFor(name string) time.Duration {
+	if timeout, ok := m.timeouts[name]; ok {
+		return timeout
+	}
+	return defaultWorkerTimeout
+}
+
+// startWatchdog cancels the run once its deadline passes and records the timeout
+// right away, so a run that ignores cancellation still shows up as timed out. Such
+// a run keeps the worker running until it returns; see Worker.
+// The returned stop function reports whether the deadline was hit.
+func (m *WorkerManager) startWatchdog(name string, run *models.WorkerRun, cancelRun func()) (stop func() bool, timeout time.Duration) {
+	timeout = m.timeoutFor(name)
+	fired := make(chan struct{})
+
+	timer := time.AfterFunc(timeout, func() {
+		defer close(fired)
+		zap.L().Error("Worker run exceeded its deadline, cancelling",
+			zap.String("worker", name),
+			zap.Duration("timeout", timeout))
+
+		cancelRun()
+		m.recordTimeout(name, run, timeout)
+	})
+
+	return func() bool {
+		if timer.Stop() {
+			return false
+		}
+		<-fired
+		return true
+	}, timeout
+}
+
+// recordTimeout marks the worker and its run as timed out before the run returns
+func (m *WorkerManager) recordTimeout(name string, run *models.WorkerRun, timeout time.Duration) {
+	now := time.Now()
+	message := fmt.Sprintf("%s after %s", ErrWorkerTimeout, timeout)
+
+	if err := m.db.Model(&models.Worker{}).
+		Where("name = ?", name).
+		Updates(map[string]any{
+			"status":     "failed",
+			"last_error": message,
+			"updated_at": now,
+		}).Error; err != nil {
+		zap.L().Error("Failed to record worker timeout", zap.String("worker", name), zap.Error(err))
+	}
+
+	if run == nil {
+		return
+	}
+
+	if err := m.db.Model(&models.WorkerRun{}).
+		Where("id = ?", run.ID).
+		Updates(map[string]any{
+			"status":      "timeout",
+			"error":       message,
+			"finished_at": now,
+			"duration_ms": now.Sub(run.StartedAt).Milliseconds(),
+			"updated_at":  now,
+		}).Error; err != nil {
+		zap.L().Error("Failed to record worker run timeout", zap.String("worker", name), zap.Error(err))
+	}
+}
+
+// ReconcileStaleRuns resets workers and runs left in the running state by instances
+// that stopped mid-run. Workers whose lease is still live on another instance keep
+// their status, as do that instance's own runs.
+func (m *WorkerManager) ReconcileStaleRuns() error {
+	var dbWorkers []models.Worker
+	if err := m.db.Find(&dbWorkers).Error; err != nil {
+		return fmt.Errorf("failed to load workers: %w", err)
+	}
+
+	// Lease expiry is on the database clock
+	var dbNow time.Time
+	if err := m.db.Raw("SELECT NOW(3)").Scan(&dbNow).Error; err != nil {
+		return fmt.Errorf("failed to read database time: %w", err)
+	}
+
+	now := time.Now()
+	for _, dbWorker := range dbWorkers {
+		liveOwner := ""
+		if dbWorker.LeaseOwner != nil && *dbWorker.LeaseOwner != m.instanceID &&
+			dbWorker.LeaseExpiresAt != nil && dbWorker.LeaseExpiresAt.After(dbNow) {
+			liveOwner = *dbWorker.LeaseOwner
+		}
+
+		if liveOwner == "" && dbWorker.Status == "running" {
+			if err := m.db.Model(&models.Worker{}).
+				Where("name = ? AND status = ?", dbWorker.Name, "running").
+				Updates(map[string]any{
+					"status":     "failed",
+					"last_error": interruptedRunError,
+					"updated_at": now,
+				}).Error; err != nil {
+				return fmt.Errorf("failed to reset worker %s: %w", dbWorker.Name, err)
+			}
+			zap.L().Warn("Reset worker left in running state", zap.String("worker", dbWorker.Name))
+		}
+
+		if err := m.failInterruptedRuns(dbWorker.Name, liveOwner); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// failInterruptedRuns marks a worker's unfinished runs as failed, except those
+// belonging to keepInstance
+func (m *WorkerManager) failInterruptedRuns(name, keepInstance string) error {
+	now := time.Now()
+	query := m.db.Model(&models.WorkerRun{}).
+		Where("worker_name = ? AND status = ?", name, "running")
+	if keepInstance != "" {
+		query = query.Where("instance_id <> ?", keepInstance)
+	}
+
+	tx := query.Updates(map[string]any{
+		"status":      "failed",
+		"error":       interruptedRunError,
+		"finished_at": now,
+		"updated_at":  now,
+	})
+	if tx.Error != nil {
+		return fmt.Errorf("failed to reconcile runs of worker %s: %w", name, tx.Error)
+	}
+	if tx.RowsAffected > 0 {
+		zap.L().Warn("Marked interrupted worker runs as failed",
+			zap.String("worker", name),
+			zap.Int64("runs", tx.RowsAffected))
+	}
+
+	return nil
+}
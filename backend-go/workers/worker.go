@@ -5,11 +5,31 @@ import (
 	"time"
 )
 
-// Worker defines the interface for all background workers
+// Worker defines the interface for all background workers.
+//
+// Run must return soon after ctx is cancelled, which happens when the run times
+// out, the lease is lost or the worker is stopped. The manager never runs a worker
+// twice at once, so until a cancelled Run returns, the worker stays running and its
+// scheduled and triggered runs are skipped.
 type Worker interface {
 	Name() string
 	Interval() time.Duration
-	Run(ctx context.Context) error
+	Run(ctx context.Context) (RunResult, error)
+}
+
+// FanslyWorker is implemented by workers that depend on the Fansly API.
+// The manager pauses them while the Fansly circuit breaker is open.
+type FanslyWorker interface {
+	UsesFansly() bool
+}
+
+// RunResult holds the counters a worker reports for a single run.
+// Fansly API calls are counted by the manager and need not be reported.
+type RunResult struct {
+	ItemsProcessed int
+	ItemsCreated   int
+	ItemsDeleted   int
+	ItemsFailed    int
 }
 
 // BaseWorker provides common functionality for all workers
@@ -0,0 +1,40 @@
+package workers
+
+import (
+	"ftoolbox/config"
+	"ftoolbox/fansly"
+	"ftoolbox/fansly/fakefansly"
+	"testing"
+)
+
+// newFakeFansly starts a fake Fansly API for the test and returns a client using it
+func newFakeFansly(t *testing.T) (*fakefansly.Server, *fansly.Client) {
+	t.Helper()
+
+	server := fakefansly.New()
+	server.Start()
+	t.Cleanup(server.Close)
+
+	client := fansly.NewClient()
+	client.SetBaseURL(server.BaseURL())
+	return server, client
+}
+
+// testConfig is the default configuration with the settings workers read filled in
+func testConfig() *config.Config {
+	return &config.Config{
+		WorkerUpdateInterval:      10000,
+		WorkerDiscoveryInterval:   600000,
+		WorkerCreatorInterval:     10000,
+		TagRefreshBatchSize:       20,
+		TagRefreshConcurrency:     4,
+		TagRefreshMinInterval:     3600000,
+		TagRefreshMaxInterval:     604800000,
+		CreatorRefreshBatchSize:   100,
+		CreatorRefreshMinInterval: 3600000,
+		CreatorRefreshMaxInterval: 604800000,
+		DiscoveryPageSize:         20,
+		DiscoveryPagesPerRun:      5,
+		DiscoveryMaxPassPages:     50,
+	}
+}